@@ -2,6 +2,7 @@ package ast
 
 import "monkey_interpreter/token"
 import "bytes"
+import "math/big"
 import "strings"
 
 // Node interface to represent node
@@ -45,11 +46,14 @@ func (p *Program) String() string {
 	return out.String()
 }
 
-// LetStatement structure
+// LetStatement structure. A let binds either Name or, when it destructures
+// its value, the names in Pattern, and the other is nil.
 type LetStatement struct {
-	Token token.Token // the token.LET token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token // the token.LET token
+	Name    *Identifier
+	Pattern Pattern
+	Type    TypeExpression // nil unless annotated
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode() {}
@@ -63,7 +67,14 @@ func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -75,10 +86,17 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
-// Identifier struct used to track identifiers
+// Identifier struct used to track identifiers. The resolver sets Local when
+// the binding is a slot of an enclosing function, Depth functions out, at
+// Index. Other identifiers are looked up by name Depth functions out, which is
+// where the global scope is once resolved and the current scope before.
 type Identifier struct {
 	Token token.Token // the token.IDENT token
 	Value string
+
+	Local bool
+	Depth int
+	Index int
 }
 
 func (i *Identifier) expressionNode() {}
@@ -115,6 +133,30 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+// YieldStatement hands Value to whoever is iterating over the generator the
+// function it is in returned, pausing the function until the next value is
+// asked for
+type YieldStatement struct {
+	Token token.Token // the 'yield' token
+	Value Expression
+}
+
+func (ys *YieldStatement) statementNode() {}
+
+// TokenLiteral and statementNode implement statement interface for YieldStatement
+func (ys *YieldStatement) TokenLiteral() string { return ys.Token.Literal }
+func (ys *YieldStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ys.TokenLiteral() + " ")
+	if ys.Value != nil {
+		out.WriteString(ys.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
 // ExpressionStatement struct
 type ExpressionStatement struct {
 	Token      token.Token // the first token of the expression
@@ -134,10 +176,12 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
-// IntegerLiteral struct
+// IntegerLiteral struct. Literals outside the range of int64 are held in Big,
+// in which case Value is zero.
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int
 }
 
 func (il *IntegerLiteral) expressionNode() {}
@@ -147,6 +191,18 @@ func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 
 func (il *IntegerLiteral) String() string { return il.Token.Literal }
 
+// FloatLiteral struct
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+
+// TokenLiteral and expressionNode implement expression interface for FloatLiteral
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 // PrefixExpression struct
 type PrefixExpression struct {
 	Token    token.Token // the prefix token
@@ -233,10 +289,37 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+// ForExpression runs Body once for each element of Iterable, binding the
+// names in Pattern to the parts of the element first
+type ForExpression struct {
+	Token    token.Token // the 'for' token
+	Pattern  Pattern
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fe *ForExpression) expressionNode() {}
+
+// TokenLiteral and expressionNode implement expression interface for ForExpression
+func (fe *ForExpression) TokenLiteral() string { return fe.Token.Literal }
+func (fe *ForExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	out.WriteString(fe.Pattern.String())
+	out.WriteString(" in ")
+	out.WriteString(fe.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fe.Body.String())
+
+	return out.String()
+}
+
 // BlockStatement struct
 type BlockStatement struct {
 	Token      token.Token // the { token
 	Statements []Statement
+	Rbrace     token.Token // the closing } token
 }
 
 func (bs *BlockStatement) statementNode() {}
@@ -253,11 +336,59 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
-// FunctionLiteral struct
+// FunctionLiteral struct. ParameterTypes has an entry for each parameter,
+// nil when it is not annotated, or is empty when none are, and so do
+// ParameterPatterns, for parameters that destructure their argument, and
+// ParameterDefaults. A pattern parameter is still in Parameters, named after
+// its pattern, which no identifier can be, to hold the argument until it is
+// destructured. When Variadic is set the last parameter is written ...name
+// and collects the arguments left over in an array. Generator is set when the
+// body yields, outside of nested functions.
 type FunctionLiteral struct {
-	Token      token.Token // The 'fn' token
-	Parameters []*Identifier
-	Body       *BlockStatement
+	Token             token.Token // The 'fn' token
+	Parameters        []*Identifier
+	ParameterTypes    []TypeExpression
+	ParameterPatterns []Pattern
+	ParameterDefaults []Expression
+	Variadic          bool
+	Generator         bool
+	ReturnType        TypeExpression // nil unless annotated
+	Body              *BlockStatement
+	Locals            []string // the names of the slots of calls, set by the resolver
+}
+
+// ParameterType returns the annotation of parameter i, or nil
+func (fl *FunctionLiteral) ParameterType(i int) TypeExpression {
+	if i < len(fl.ParameterTypes) {
+		return fl.ParameterTypes[i]
+	}
+	return nil
+}
+
+// ParameterPattern returns the pattern of parameter i, or nil
+func (fl *FunctionLiteral) ParameterPattern(i int) Pattern {
+	if i < len(fl.ParameterPatterns) {
+		return fl.ParameterPatterns[i]
+	}
+	return nil
+}
+
+// ParameterDefault returns the default value of parameter i, or nil
+func (fl *FunctionLiteral) ParameterDefault(i int) Expression {
+	if i < len(fl.ParameterDefaults) {
+		return fl.ParameterDefaults[i]
+	}
+	return nil
+}
+
+// Required returns the number of parameters an argument must be given for
+func (fl *FunctionLiteral) Required() int {
+	for i := range fl.Parameters {
+		if fl.ParameterDefault(i) != nil || fl.Variadic && i == len(fl.Parameters)-1 {
+			return i
+		}
+	}
+	return len(fl.Parameters)
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -268,14 +399,27 @@ func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
+	for i, p := range fl.Parameters {
+		param := p.String()
+		if fl.Variadic && i == len(fl.Parameters)-1 {
+			param = "..." + param
+		}
+		if t := fl.ParameterType(i); t != nil {
+			param += ": " + t.String()
+		}
+		if d := fl.ParameterDefault(i); d != nil {
+			param += " = " + d.String()
+		}
+		params = append(params, param)
 	}
 
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
+	if fl.ReturnType != nil {
+		out.WriteString("-> " + fl.ReturnType.String() + " ")
+	}
 	out.WriteString(fl.Body.String())
 
 	return out.String()
@@ -308,6 +452,61 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 
+// InterpolatedString is a string literal with expressions embedded as
+// ${expression}. Parts alternate between string literals for the text, which
+// may be empty, and the expressions, starting and ending with text.
+type InterpolatedString struct {
+	Token token.Token // the STRING_START token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode() {}
+
+// TokenLiteral and expressionNode implement expression interface for InterpolatedString
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for i, part := range is.Parts {
+		if i%2 == 0 {
+			out.WriteString(part.String())
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
+// SpreadExpression is an argument written ...array, which passes the
+// elements of the array as arguments
+type SpreadExpression struct {
+	Token token.Token // the '...' token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode() {}
+
+// TokenLiteral and expressionNode implement expression interface for SpreadExpression
+func (se *SpreadExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpreadExpression) String() string       { return "..." + se.Value.String() }
+
+// NamedArgument is an argument written name: value, which passes value for
+// the parameter called name wherever it is in the parameter list
+type NamedArgument struct {
+	Token token.Token // the ':' token
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode() {}
+
+// TokenLiteral and expressionNode implement expression interface for NamedArgument
+func (na *NamedArgument) TokenLiteral() string { return na.Token.Literal }
+func (na *NamedArgument) String() string       { return na.Name.String() + ": " + na.Value.String() }
+
 // StringLiteral struct
 type StringLiteral struct {
 	Token token.Token
@@ -368,10 +567,59 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// SliceExpression struct for left[start:end], where Start and End are nil
+// when omitted
+type SliceExpression struct {
+	Token token.Token // the [ token
+	Left  Expression
+	Start Expression
+	End   Expression
+}
+
+func (se *SliceExpression) expressionNode() {}
+
+// TokenLiteral and expressionNode implement expression interface for SliceExpression
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// MemberExpression struct for object.property, which looks up the string
+// "property" like an index. Property is a name rather than a reference to a
+// binding, so Inspect does not visit it.
+type MemberExpression struct {
+	Token    token.Token // the token.DOT token
+	Object   Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode() {}
+
+// TokenLiteral and expressionNode implement expression interface for MemberExpression
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	return "(" + me.Object.String() + "." + me.Property.String() + ")"
+}
+
 // HashLiteral struct
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
+	Keys  []Expression // keys of Pairs in source order
 }
 
 func (hl *HashLiteral) expressionNode() {}
@@ -382,8 +630,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -392,3 +640,86 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+// TokenOf returns the token a node was parsed from so callers can report its position
+func TokenOf(node Node) token.Token {
+	switch node := node.(type) {
+	case *Program:
+		if len(node.Statements) > 0 {
+			return TokenOf(node.Statements[0])
+		}
+	case *LetStatement:
+		return node.Token
+	case *ReturnStatement:
+		return node.Token
+	case *YieldStatement:
+		return node.Token
+	case *ExpressionStatement:
+		return node.Token
+	case *BlockStatement:
+		return node.Token
+	case *Identifier:
+		return node.Token
+	case *IntegerLiteral:
+		return node.Token
+	case *FloatLiteral:
+		return node.Token
+	case *PrefixExpression:
+		return node.Token
+	case *InfixExpression:
+		return node.Token
+	case *Boolean:
+		return node.Token
+	case *IfExpression:
+		return node.Token
+	case *ForExpression:
+		return node.Token
+	case *FunctionLiteral:
+		return node.Token
+	case *CallExpression:
+		return node.Token
+	case *StringLiteral:
+		return node.Token
+	case *ArrayLiteral:
+		return node.Token
+	case *IndexExpression:
+		return node.Token
+	case *SliceExpression:
+		return node.Token
+	case *MemberExpression:
+		return node.Token
+	case *HashLiteral:
+		return node.Token
+	case *InterpolatedString:
+		return node.Token
+	case *SpreadExpression:
+		return node.Token
+	case *NamedArgument:
+		return node.Name.Token
+	case *MatchExpression:
+		return node.Token
+	case *MatchArm:
+		return TokenOf(node.Pattern)
+	case *LiteralPattern:
+		return node.Token
+	case *WildcardPattern:
+		return node.Token
+	case *BindingPattern:
+		return node.Name.Token
+	case *ArrayPattern:
+		return node.Token
+	case *HashPattern:
+		return node.Token
+	case *DefaultPattern:
+		return TokenOf(node.Pattern)
+	case *NamedType:
+		return node.Token
+	case *ArrayType:
+		return node.Token
+	case *HashType:
+		return node.Token
+	case *FunctionType:
+		return node.Token
+	}
+	return token.Token{}
+}
@@ -26,3 +26,36 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestInspect(t *testing.T) {
+	x := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"}
+	y := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "y"}, Value: "y"}
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token: token.Token{Type: token.LET, Literal: "let"},
+				Name:  x,
+				Value: &FunctionLiteral{
+					Token:      token.Token{Type: token.FUNCTION, Literal: "fn"},
+					Parameters: []*Identifier{y},
+					Body: &BlockStatement{
+						Statements: []Statement{&ExpressionStatement{Expression: y}},
+					},
+				},
+			},
+		},
+	}
+
+	var visited []string
+	Inspect(program, func(n Node) bool {
+		if ident, ok := n.(*Identifier); ok {
+			visited = append(visited, ident.Value)
+		}
+		_, isFunction := n.(*FunctionLiteral)
+		return !isFunction
+	})
+
+	if len(visited) != 1 || visited[0] != "x" {
+		t.Errorf("Inspect visited wrong identifiers. got=%v", visited)
+	}
+}
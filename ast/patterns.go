@@ -0,0 +1,178 @@
+package ast
+
+import (
+	"bytes"
+	"monkey_interpreter/token"
+	"strings"
+)
+
+// MatchExpression evaluates the result of the first arm whose pattern matches
+// Subject and whose guard, if any, holds
+type MatchExpression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+	Rbrace  token.Token // the closing } token
+}
+
+func (me *MatchExpression) expressionNode() {}
+
+// TokenLiteral and expressionNode implement Expression for MatchExpression
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// MatchArm is a single `pattern if guard => result` of a match
+type MatchArm struct {
+	Token   token.Token // the '=>' token
+	Pattern Pattern
+	Guard   Expression // nil unless given
+	Result  Expression
+	Locals  []string // the slots of the environment the arm is tried in, set by the resolver
+}
+
+// TokenLiteral implements Node for MatchArm
+func (ma *MatchArm) TokenLiteral() string { return ma.Token.Literal }
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if " + ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Result.String())
+
+	return out.String()
+}
+
+// Pattern describes the shape of values and names parts of them
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// LiteralPattern matches values equal to a literal, which may be negated
+type LiteralPattern struct {
+	Token token.Token // the first token of the literal
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode() {}
+
+// TokenLiteral and patternNode implement Pattern for LiteralPattern
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Token.Literal }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+
+// WildcardPattern, written _, matches anything
+type WildcardPattern struct {
+	Token token.Token // the '_' token
+}
+
+func (wp *WildcardPattern) patternNode() {}
+
+// TokenLiteral and patternNode implement Pattern for WildcardPattern
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+func (wp *WildcardPattern) String() string       { return "_" }
+
+// BindingPattern matches anything and binds it to Name
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (bp *BindingPattern) patternNode() {}
+
+// TokenLiteral and patternNode implement Pattern for BindingPattern
+func (bp *BindingPattern) TokenLiteral() string { return bp.Name.TokenLiteral() }
+func (bp *BindingPattern) String() string       { return bp.Name.String() }
+
+// ArrayPattern matches arrays whose elements match Elements. Without Rest the
+// lengths must be equal; with it longer arrays match too and Rest matches the
+// array of the elements left over. Elements with defaults may be missing.
+type ArrayPattern struct {
+	Token    token.Token // the '[' token
+	Elements []Pattern
+	Rest     Pattern // nil unless the pattern ends with ...rest
+}
+
+func (ap *ArrayPattern) patternNode() {}
+
+// TokenLiteral and patternNode implement Pattern for ArrayPattern
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPattern matches hashes that have every key in Keys with a value
+// matching the pattern at the same index of Values, unless the pattern has a
+// default. Other keys are ignored.
+type HashPattern struct {
+	Token  token.Token // the '{' token
+	Keys   []Expression
+	Values []Pattern
+}
+
+func (hp *HashPattern) patternNode() {}
+
+// TokenLiteral and patternNode implement Pattern for HashPattern
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	pairs := []string{}
+	for i, key := range hp.Keys {
+		pairs = append(pairs, key.String()+": "+hp.Values[i].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// DefaultPattern is an element of an array or hash pattern that may be
+// missing, in which case Pattern matches the value of Default instead
+type DefaultPattern struct {
+	Token   token.Token // the '=' token
+	Pattern Pattern
+	Default Expression
+}
+
+func (dp *DefaultPattern) patternNode() {}
+
+// TokenLiteral and patternNode implement Pattern for DefaultPattern
+func (dp *DefaultPattern) TokenLiteral() string { return dp.Token.Literal }
+func (dp *DefaultPattern) String() string {
+	return dp.Pattern.String() + " = " + dp.Default.String()
+}
+
+// PatternNames returns the identifiers p binds, in order
+func PatternNames(p Pattern) []*Identifier {
+	names := []*Identifier{}
+	Inspect(p, func(n Node) bool {
+		switch n := n.(type) {
+		case *BindingPattern:
+			names = append(names, n.Name)
+		case *DefaultPattern:
+			names = append(names, PatternNames(n.Pattern)...)
+			return false
+		}
+		return true
+	})
+	return names
+}
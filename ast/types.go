@@ -0,0 +1,70 @@
+package ast
+
+import (
+	"monkey_interpreter/token"
+	"strings"
+)
+
+// TypeExpression is a type annotation, which the evaluator ignores
+type TypeExpression interface {
+	Node
+	typeNode()
+}
+
+// NamedType is a type written as its name, such as int or string
+type NamedType struct {
+	Token token.Token // the token.IDENT token
+	Name  string
+}
+
+func (nt *NamedType) typeNode() {}
+
+// TokenLiteral and typeNode implement TypeExpression for NamedType
+func (nt *NamedType) TokenLiteral() string { return nt.Token.Literal }
+func (nt *NamedType) String() string       { return nt.Name }
+
+// ArrayType is the type of arrays of Element, written [Element]
+type ArrayType struct {
+	Token   token.Token // the '[' token
+	Element TypeExpression
+}
+
+func (at *ArrayType) typeNode() {}
+
+// TokenLiteral and typeNode implement TypeExpression for ArrayType
+func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) String() string       { return "[" + at.Element.String() + "]" }
+
+// HashType is the type of hashes from Key to Value, written {Key: Value}
+type HashType struct {
+	Token token.Token // the '{' token
+	Key   TypeExpression
+	Value TypeExpression
+}
+
+func (ht *HashType) typeNode() {}
+
+// TokenLiteral and typeNode implement TypeExpression for HashType
+func (ht *HashType) TokenLiteral() string { return ht.Token.Literal }
+func (ht *HashType) String() string {
+	return "{" + ht.Key.String() + ": " + ht.Value.String() + "}"
+}
+
+// FunctionType is the type of functions, written fn(Parameters) -> Result
+type FunctionType struct {
+	Token      token.Token // the 'fn' token
+	Parameters []TypeExpression
+	Result     TypeExpression
+}
+
+func (ft *FunctionType) typeNode() {}
+
+// TokenLiteral and typeNode implement TypeExpression for FunctionType
+func (ft *FunctionType) TokenLiteral() string { return ft.Token.Literal }
+func (ft *FunctionType) String() string {
+	params := []string{}
+	for _, p := range ft.Parameters {
+		params = append(params, p.String())
+	}
+	return "fn(" + strings.Join(params, ", ") + ") -> " + ft.Result.String()
+}
@@ -0,0 +1,135 @@
+package ast
+
+// Inspect traverses the tree rooted at node in depth-first order, calling f
+// for each node. The children of a node are skipped when f returns false.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, s := range node.Statements {
+			Inspect(s, f)
+		}
+	case *LetStatement:
+		if node.Pattern != nil {
+			Inspect(node.Pattern, f)
+		} else {
+			Inspect(node.Name, f)
+		}
+		inspectExpression(node.Value, f)
+	case *ReturnStatement:
+		inspectExpression(node.ReturnValue, f)
+	case *YieldStatement:
+		inspectExpression(node.Value, f)
+	case *ExpressionStatement:
+		inspectExpression(node.Expression, f)
+	case *BlockStatement:
+		for _, s := range node.Statements {
+			Inspect(s, f)
+		}
+	case *PrefixExpression:
+		inspectExpression(node.Right, f)
+	case *InfixExpression:
+		inspectExpression(node.Left, f)
+		inspectExpression(node.Right, f)
+	case *IfExpression:
+		inspectExpression(node.Condition, f)
+		if node.Consequence != nil {
+			Inspect(node.Consequence, f)
+		}
+		if node.Alternative != nil {
+			Inspect(node.Alternative, f)
+		}
+	case *ForExpression:
+		if node.Pattern != nil {
+			Inspect(node.Pattern, f)
+		}
+		inspectExpression(node.Iterable, f)
+		if node.Body != nil {
+			Inspect(node.Body, f)
+		}
+	case *FunctionLiteral:
+		for i, p := range node.Parameters {
+			if pattern := node.ParameterPattern(i); pattern != nil {
+				Inspect(pattern, f)
+			} else {
+				Inspect(p, f)
+			}
+			inspectExpression(node.ParameterDefault(i), f)
+		}
+		if node.Body != nil {
+			Inspect(node.Body, f)
+		}
+	case *CallExpression:
+		inspectExpression(node.Function, f)
+		for _, a := range node.Arguments {
+			inspectExpression(a, f)
+		}
+	case *ArrayLiteral:
+		for _, el := range node.Elements {
+			inspectExpression(el, f)
+		}
+	case *IndexExpression:
+		inspectExpression(node.Left, f)
+		inspectExpression(node.Index, f)
+	case *SliceExpression:
+		inspectExpression(node.Left, f)
+		inspectExpression(node.Start, f)
+		inspectExpression(node.End, f)
+	case *MemberExpression:
+		inspectExpression(node.Object, f)
+	case *HashLiteral:
+		for _, key := range node.Keys {
+			inspectExpression(key, f)
+			inspectExpression(node.Pairs[key], f)
+		}
+	case *InterpolatedString:
+		for _, part := range node.Parts {
+			inspectExpression(part, f)
+		}
+	case *SpreadExpression:
+		inspectExpression(node.Value, f)
+	case *NamedArgument:
+		Inspect(node.Name, f)
+		inspectExpression(node.Value, f)
+	case *MatchExpression:
+		inspectExpression(node.Subject, f)
+		for _, arm := range node.Arms {
+			Inspect(arm, f)
+		}
+	case *MatchArm:
+		if node.Pattern != nil {
+			Inspect(node.Pattern, f)
+		}
+		inspectExpression(node.Guard, f)
+		inspectExpression(node.Result, f)
+	case *LiteralPattern:
+		inspectExpression(node.Value, f)
+	case *BindingPattern:
+		Inspect(node.Name, f)
+	case *ArrayPattern:
+		for _, el := range node.Elements {
+			Inspect(el, f)
+		}
+		if node.Rest != nil {
+			Inspect(node.Rest, f)
+		}
+	case *HashPattern:
+		for i, key := range node.Keys {
+			inspectExpression(key, f)
+			Inspect(node.Values[i], f)
+		}
+	case *DefaultPattern:
+		Inspect(node.Pattern, f)
+		inspectExpression(node.Default, f)
+	}
+}
+
+// inspectExpression guards against the nil expressions left behind by parser errors
+func inspectExpression(exp Expression, f func(Node) bool) {
+	if exp != nil {
+		Inspect(exp, f)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"monkey_interpreter/typecheck"
+	"os"
+)
+
+// runCheck implements the check subcommand and returns the process exit code
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey check file...\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		errors, parseErrors := typecheck.Source(string(source))
+		for _, msg := range parseErrors {
+			fmt.Printf("%s: parser error: %s\n", path, msg)
+			status = 1
+		}
+		for _, e := range errors {
+			fmt.Printf("%s:%s\n", path, e)
+			status = 1
+		}
+	}
+
+	return status
+}
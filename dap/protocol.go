@@ -0,0 +1,119 @@
+package dap
+
+import "encoding/json"
+
+// The subset of the Debug Adapter Protocol messages the adapter uses
+
+// request is a message from the client
+type request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// response answers a request
+type response struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+// event is a message the adapter sends on its own
+type event struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+// LaunchArguments starts a Monkey script. The allow fields grant it
+// capabilities like the -allow-* flags of monkey run; it may print unless
+// AllowOutput is false.
+type LaunchArguments struct {
+	Program     string `json:"program"`
+	StopOnEntry bool   `json:"stopOnEntry"`
+	NoDebug     bool   `json:"noDebug"`
+
+	AllowRead   []string `json:"allowRead,omitempty"`
+	AllowWrite  []string `json:"allowWrite,omitempty"`
+	AllowEnv    []string `json:"allowEnv,omitempty"`
+	AllowClock  bool     `json:"allowClock,omitempty"`
+	AllowRandom bool     `json:"allowRandom,omitempty"`
+	AllowOutput *bool    `json:"allowOutput,omitempty"`
+	AllowAll    bool     `json:"allowAll,omitempty"`
+}
+
+// Source is a file shown by the client
+type Source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// SourceBreakpoint is a breakpoint requested by the client
+type SourceBreakpoint struct {
+	Line int `json:"line"`
+}
+
+// SetBreakpointsArguments replaces the breakpoints of a source
+type SetBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints"`
+}
+
+// Breakpoint reports whether a requested breakpoint was accepted
+type Breakpoint struct {
+	Verified bool   `json:"verified"`
+	Line     int    `json:"line"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Thread is a thread of execution; Monkey has exactly one
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// StackFrame is a level of the call stack
+type StackFrame struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Source *Source `json:"source,omitempty"`
+	Line   int     `json:"line"`
+	Column int     `json:"column"`
+}
+
+// FrameArguments names a stack frame
+type FrameArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+// Scope is a group of variables in a frame
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+// VariablesArguments asks for the children of a reference
+type VariablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+// Variable is a named value, expandable when VariablesReference is set
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// EvaluateArguments evaluates an expression in a frame
+type EvaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    int    `json:"frameId"`
+}
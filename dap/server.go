@@ -0,0 +1,444 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/debugger"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/wire"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// threadID is the only thread a Monkey program runs on
+const threadID = 1
+
+// Server is a debug adapter speaking the Debug Adapter Protocol over a pair of streams
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	writeMu sync.Mutex // guards out and seq, written from the program's goroutine too
+	seq     int
+
+	d       *debugger.Debugger
+	program *ast.Program
+	path    string
+	started bool
+	resume  chan debugger.Action
+
+	// mu guards the state captured while the program is paused
+	mu     sync.Mutex
+	paused bool
+	stack  []debugger.StackFrame
+	refs   []interface{} // values behind variablesReference n, stored at n-1
+}
+
+// NewServer returns an adapter reading requests from in and writing to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		in:     bufio.NewReader(in),
+		out:    out,
+		resume: make(chan debugger.Action),
+	}
+	s.d = debugger.New(s.onStop)
+	s.d.Interpreter.Policy = evaluator.Policy{Output: true}
+	s.d.Interpreter.Out = outputWriter{s}
+	return s
+}
+
+// Serve handles requests until the client disconnects or closes the input
+func (s *Server) Serve() error {
+	for {
+		body, err := wire.Read(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return err
+		}
+
+		done, err := s.handle(&req)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+func (s *Server) send(v interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.seq++
+	switch m := v.(type) {
+	case *response:
+		m.Seq, m.Type = s.seq, "response"
+	case *event:
+		m.Seq, m.Type = s.seq, "event"
+	}
+	return wire.Write(s.out, v)
+}
+
+func (s *Server) reply(req *request, body interface{}) error {
+	return s.send(&response{RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body})
+}
+
+func (s *Server) fail(req *request, format string, a ...interface{}) error {
+	msg := fmt.Sprintf(format, a...)
+	return s.send(&response{RequestSeq: req.Seq, Command: req.Command, Message: msg})
+}
+
+func (s *Server) emit(name string, body interface{}) error {
+	return s.send(&event{Event: name, Body: body})
+}
+
+// handle answers a request, reporting whether the session is over
+func (s *Server) handle(req *request) (bool, error) {
+	switch req.Command {
+	case "initialize":
+		if err := s.reply(req, map[string]bool{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}); err != nil {
+			return false, err
+		}
+		return false, s.emit("initialized", nil)
+
+	case "launch":
+		var args LaunchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return false, s.fail(req, "invalid launch arguments: %s", err)
+		}
+		if err := s.load(args); err != nil {
+			return false, s.fail(req, "%s", err)
+		}
+		return false, s.reply(req, nil)
+
+	case "setBreakpoints":
+		var args SetBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return false, s.fail(req, "invalid breakpoints: %s", err)
+		}
+		s.d.ClearBreakpoints()
+		breakpoints := []Breakpoint{}
+		for _, bp := range args.Breakpoints {
+			s.d.SetBreakpoint(bp.Line)
+			breakpoints = append(breakpoints, Breakpoint{Verified: true, Line: bp.Line})
+		}
+		return false, s.reply(req, map[string]interface{}{"breakpoints": breakpoints})
+
+	case "configurationDone":
+		if s.program == nil {
+			return false, s.fail(req, "no program has been launched")
+		}
+		if err := s.reply(req, nil); err != nil {
+			return false, err
+		}
+		if !s.started {
+			s.started = true
+			go s.run()
+		}
+		return false, nil
+
+	case "threads":
+		return false, s.reply(req, map[string]interface{}{"threads": []Thread{{ID: threadID, Name: "main"}}})
+
+	case "stackTrace":
+		return false, s.reply(req, s.stackTrace())
+
+	case "scopes":
+		var args FrameArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return false, s.fail(req, "invalid scopes arguments: %s", err)
+		}
+		scopes, ok := s.scopes(args.FrameID)
+		if !ok {
+			return false, s.fail(req, "unknown frame %d", args.FrameID)
+		}
+		return false, s.reply(req, map[string]interface{}{"scopes": scopes})
+
+	case "variables":
+		var args VariablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return false, s.fail(req, "invalid variables arguments: %s", err)
+		}
+		return false, s.reply(req, map[string]interface{}{"variables": s.variables(args.VariablesReference)})
+
+	case "evaluate":
+		var args EvaluateArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return false, s.fail(req, "invalid evaluate arguments: %s", err)
+		}
+		return false, s.evaluate(req, args)
+
+	case "continue":
+		return false, s.step(req, debugger.Continue, map[string]bool{"allThreadsContinued": true})
+	case "next":
+		return false, s.step(req, debugger.StepOver, nil)
+	case "stepIn":
+		return false, s.step(req, debugger.StepIn, nil)
+	case "stepOut":
+		return false, s.step(req, debugger.StepOut, nil)
+	case "pause":
+		s.d.Pause()
+		return false, s.reply(req, nil)
+	case "terminate":
+		return false, s.step(req, debugger.Quit, nil)
+
+	case "disconnect":
+		if err := s.reply(req, nil); err != nil {
+			return true, err
+		}
+		if s.takePause() {
+			s.resume <- debugger.Quit
+		}
+		return true, nil
+
+	default:
+		return false, s.fail(req, "unsupported request %q", req.Command)
+	}
+}
+
+// load reads and parses the program to debug
+func (s *Server) load(args LaunchArguments) error {
+	source, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fmt.Errorf("%s does not parse: %s", args.Program, strings.Join(p.Errors(), "; "))
+	}
+
+	s.program, s.path = program, args.Program
+	s.d.Interpreter.File = args.Program
+	s.d.Interpreter.Policy = launchPolicy(args)
+	s.d.StopOnEntry = args.StopOnEntry && !args.NoDebug
+	if args.NoDebug {
+		s.d.OnStop = func(debugger.Stop) debugger.Action { return debugger.Continue }
+	}
+	return nil
+}
+
+// launchPolicy returns the Policy the allow fields of args describe
+func launchPolicy(args LaunchArguments) evaluator.Policy {
+	if args.AllowAll {
+		return evaluator.AllowAll
+	}
+	return evaluator.Policy{
+		Read:   args.AllowRead,
+		Write:  args.AllowWrite,
+		Env:    args.AllowEnv,
+		Clock:  args.AllowClock,
+		Random: args.AllowRandom,
+		Output: args.AllowOutput == nil || *args.AllowOutput,
+	}
+}
+
+// run evaluates the program, reporting its result once it finishes
+func (s *Server) run() {
+	evaluated := s.d.Run(s.program, object.NewEnvironment())
+
+	exitCode := 0
+	if evaluated != nil {
+		category := "console"
+		if evaluated.Type() == object.ERROR_OBJ {
+			category, exitCode = "stderr", 1
+		}
+		s.emit("output", map[string]string{"category": category, "output": evaluated.Inspect() + "\n"})
+	}
+	s.emit("terminated", nil)
+	s.emit("exited", map[string]int{"exitCode": exitCode})
+}
+
+// outputWriter forwards what the program prints to the client as output events
+type outputWriter struct {
+	s *Server
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	if err := w.s.emit("output", map[string]string{"category": "stdout", "output": string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// onStop runs on the program's goroutine, which it blocks until the client resumes it
+func (s *Server) onStop(stop debugger.Stop) debugger.Action {
+	s.mu.Lock()
+	s.paused = true
+	s.stack = s.d.Stack()
+	s.refs = nil
+	s.mu.Unlock()
+
+	s.emit("stopped", map[string]interface{}{
+		"reason":            stop.Reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+
+	return <-s.resume
+}
+
+// takePause clears the paused state, reporting whether the program was paused
+func (s *Server) takePause() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paused := s.paused
+	s.paused, s.stack, s.refs = false, nil, nil
+	return paused
+}
+
+// step replies to req and then resumes a paused program with action
+func (s *Server) step(req *request, action debugger.Action, body interface{}) error {
+	paused := s.takePause()
+	if err := s.reply(req, body); err != nil {
+		return err
+	}
+	if paused {
+		s.resume <- action
+	}
+	return nil
+}
+
+func (s *Server) stackTrace() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source := &Source{Name: filepath.Base(s.path), Path: s.path}
+	frames := []StackFrame{}
+	for i, frame := range s.stack {
+		frames = append(frames, StackFrame{ID: i + 1, Name: frame.Name, Source: source, Line: frame.Line, Column: 1})
+	}
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}
+}
+
+// frameEnv returns the environment of a paused frame; mu must be held
+func (s *Server) frameEnv(frameID int) (*object.Environment, bool) {
+	if frameID < 1 || frameID > len(s.stack) {
+		return nil, false
+	}
+	return s.stack[frameID-1].Env, true
+}
+
+// reference hands out a variablesReference for v; mu must be held
+func (s *Server) reference(v interface{}) int {
+	s.refs = append(s.refs, v)
+	return len(s.refs)
+}
+
+func (s *Server) scopes(frameID int) ([]Scope, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	env, ok := s.frameEnv(frameID)
+	if !ok {
+		return nil, false
+	}
+
+	scopes := []Scope{}
+	for e := env; e != nil; e = e.Outer() {
+		name := "Closure"
+		switch {
+		case e.Outer() == nil:
+			name = "Globals"
+		case e == env:
+			name = "Locals"
+		}
+		scopes = append(scopes, Scope{Name: name, VariablesReference: s.reference(e)})
+	}
+	return scopes, true
+}
+
+func (s *Server) variables(ref int) []Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	variables := []Variable{}
+	if ref < 1 || ref > len(s.refs) {
+		return variables
+	}
+
+	switch v := s.refs[ref-1].(type) {
+	case *object.Environment:
+		for _, name := range v.Names() {
+			val, _ := v.Get(name)
+			variables = append(variables, s.variable(name, val))
+		}
+	case *object.Array:
+		for i, el := range v.Elements() {
+			variables = append(variables, s.variable("["+strconv.Itoa(i)+"]", el))
+		}
+	case *object.Hash:
+		for _, pair := range v.Items() {
+			variables = append(variables, s.variable(pair.Key.Inspect(), pair.Value))
+		}
+	case *object.Module:
+		for _, name := range v.Env.Names() {
+			val, _ := v.Env.Get(name)
+			variables = append(variables, s.variable(name, val))
+		}
+	}
+	return variables
+}
+
+// variable describes val, making arrays and hashes expandable; mu must be held
+func (s *Server) variable(name string, val object.Object) Variable {
+	v := Variable{Name: name, Value: debugger.Summarize(val), Type: string(val.Type())}
+	switch val := val.(type) {
+	case *object.Array:
+		if val.Len() > 0 {
+			v.VariablesReference = s.reference(val)
+		}
+	case *object.Hash:
+		if val.Len() > 0 {
+			v.VariablesReference = s.reference(val)
+		}
+	case *object.Module:
+		v.VariablesReference = s.reference(val)
+	}
+	return v
+}
+
+func (s *Server) evaluate(req *request, args EvaluateArguments) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	env, ok := s.frameEnv(args.FrameID)
+	if !ok {
+		return s.fail(req, "expressions can only be evaluated while paused")
+	}
+
+	evaluated, errors := debugger.Evaluate(args.Expression, env)
+	if len(errors) != 0 {
+		return s.fail(req, "%s", strings.Join(errors, "; "))
+	}
+	if evaluated == nil {
+		return s.reply(req, map[string]interface{}{"result": "", "variablesReference": 0})
+	}
+
+	v := s.variable(args.Expression, evaluated)
+	return s.reply(req, map[string]interface{}{
+		"result":             v.Value,
+		"type":               v.Type,
+		"variablesReference": v.VariablesReference,
+	})
+}
@@ -0,0 +1,329 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"monkey_interpreter/wire"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const program = `let add = fn(a, b) {
+  let sum = a + b;
+  sum
+};
+let list = [1, [2, 3], {"k": 4}];
+let x = add(1, 2);
+x`
+
+type message struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client drives an adapter over pipes the way an editor would
+type client struct {
+	t       *testing.T
+	w       io.Writer
+	msgs    chan message
+	pending []message
+	seq     int
+}
+
+func newClient(t *testing.T) *client {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	go func() {
+		NewServer(inR, outW).Serve()
+		outW.Close()
+	}()
+
+	c := &client{t: t, w: inW, msgs: make(chan message, 64)}
+	go func() {
+		r := bufio.NewReader(outR)
+		for {
+			body, err := wire.Read(r)
+			if err != nil {
+				close(c.msgs)
+				return
+			}
+			var m message
+			if err := json.Unmarshal(body, &m); err != nil {
+				t.Errorf("invalid message %q: %v", body, err)
+			}
+			c.msgs <- m
+		}
+	}()
+
+	return c
+}
+
+func (c *client) request(command string, args interface{}) int {
+	c.seq++
+	msg := map[string]interface{}{"seq": c.seq, "type": "request", "command": command}
+	if args != nil {
+		msg["arguments"] = args
+	}
+	if err := wire.Write(c.w, msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.seq
+}
+
+// next returns the next message matching match, keeping the others for later
+func (c *client) next(match func(message) bool, what string) message {
+	for i, m := range c.pending {
+		if match(m) {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return m
+		}
+	}
+
+	for {
+		select {
+		case m, ok := <-c.msgs:
+			if !ok {
+				c.t.Fatalf("adapter closed before %s", what)
+			}
+			if match(m) {
+				return m
+			}
+			c.pending = append(c.pending, m)
+		case <-time.After(5 * time.Second):
+			c.t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// call sends a request and decodes the body of its successful response into body
+func (c *client) call(command string, args interface{}, body interface{}) {
+	seq := c.request(command, args)
+	m := c.next(func(m message) bool { return m.Type == "response" && m.RequestSeq == seq }, command+" response")
+	if !m.Success {
+		c.t.Fatalf("%s failed: %s", command, m.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(m.Body, body); err != nil {
+			c.t.Fatalf("%s returned invalid body %s: %v", command, m.Body, err)
+		}
+	}
+}
+
+func (c *client) event(name string, body interface{}) {
+	m := c.next(func(m message) bool { return m.Type == "event" && m.Event == name }, name+" event")
+	if body != nil {
+		if err := json.Unmarshal(m.Body, body); err != nil {
+			c.t.Fatalf("%s event has invalid body %s: %v", name, m.Body, err)
+		}
+	}
+}
+
+func (c *client) variables(ref int) map[string]Variable {
+	var body struct {
+		Variables []Variable `json:"variables"`
+	}
+	c.call("variables", VariablesArguments{VariablesReference: ref}, &body)
+
+	vars := make(map[string]Variable)
+	for _, v := range body.Variables {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+func writeProgram(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "program.mk")
+	if err := os.WriteFile(path, []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDebugSession(t *testing.T) {
+	path := writeProgram(t)
+	c := newClient(t)
+
+	c.call("initialize", map[string]string{"adapterID": "monkey"}, nil)
+	c.event("initialized", nil)
+	c.call("launch", LaunchArguments{Program: path}, nil)
+
+	var bps struct {
+		Breakpoints []Breakpoint `json:"breakpoints"`
+	}
+	c.call("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: path},
+		Breakpoints: []SourceBreakpoint{{Line: 2}},
+	}, &bps)
+	if len(bps.Breakpoints) != 1 || !bps.Breakpoints[0].Verified {
+		t.Fatalf("breakpoint not verified. got=%+v", bps)
+	}
+	c.call("configurationDone", nil, nil)
+
+	var stopped struct {
+		Reason   string `json:"reason"`
+		ThreadID int    `json:"threadId"`
+	}
+	c.event("stopped", &stopped)
+	if stopped.Reason != "breakpoint" || stopped.ThreadID != threadID {
+		t.Fatalf("wrong stop. got=%+v", stopped)
+	}
+
+	var trace struct {
+		StackFrames []StackFrame `json:"stackFrames"`
+	}
+	c.call("stackTrace", map[string]int{"threadId": threadID}, &trace)
+	frames := trace.StackFrames
+	if len(frames) != 2 || frames[0].Name != "add" || frames[0].Line != 2 || frames[1].Line != 6 {
+		t.Fatalf("wrong stack trace. got=%+v", frames)
+	}
+
+	var scopes struct {
+		Scopes []Scope `json:"scopes"`
+	}
+	c.call("scopes", FrameArguments{FrameID: frames[0].ID}, &scopes)
+	if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Locals" || scopes.Scopes[1].Name != "Globals" {
+		t.Fatalf("wrong scopes. got=%+v", scopes.Scopes)
+	}
+
+	locals := c.variables(scopes.Scopes[0].VariablesReference)
+	if locals["a"].Value != "1" || locals["b"].Value != "2" || locals["a"].Type != "INTEGER" {
+		t.Errorf("wrong locals. got=%+v", locals)
+	}
+
+	globals := c.variables(scopes.Scopes[1].VariablesReference)
+	if globals["add"].Value != "fn(a, b) {...}" || globals["list"].VariablesReference == 0 {
+		t.Fatalf("wrong globals. got=%+v", globals)
+	}
+
+	list := c.variables(globals["list"].VariablesReference)
+	if list["[0]"].Value != "1" || list["[1]"].Value != "[2, 3]" || list["[1]"].VariablesReference == 0 {
+		t.Fatalf("wrong array children. got=%+v", list)
+	}
+	hash := c.variables(list["[2]"].VariablesReference)
+	if hash["k"].Value != "4" {
+		t.Errorf("wrong hash children. got=%+v", hash)
+	}
+
+	var evaluated struct {
+		Result string `json:"result"`
+	}
+	c.call("evaluate", EvaluateArguments{Expression: "a + b", FrameID: frames[0].ID}, &evaluated)
+	if evaluated.Result != "3" {
+		t.Errorf("wrong evaluation. got=%q", evaluated.Result)
+	}
+
+	c.call("next", map[string]int{"threadId": threadID}, nil)
+	c.event("stopped", &stopped)
+	c.call("stackTrace", map[string]int{"threadId": threadID}, &trace)
+	if stopped.Reason != "step" || trace.StackFrames[0].Line != 3 {
+		t.Fatalf("next stopped in the wrong place. got=%+v at %+v", stopped, trace.StackFrames)
+	}
+
+	c.call("continue", map[string]int{"threadId": threadID}, nil)
+
+	var output struct {
+		Output string `json:"output"`
+	}
+	c.event("output", &output)
+	if output.Output != "3\n" {
+		t.Errorf("wrong program output. got=%q", output.Output)
+	}
+	c.event("terminated", nil)
+
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	c.event("exited", &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("wrong exit code. got=%d", exited.ExitCode)
+	}
+
+	c.call("disconnect", nil, nil)
+}
+
+func TestStopOnEntryAndTerminate(t *testing.T) {
+	path := writeProgram(t)
+	c := newClient(t)
+
+	c.call("initialize", nil, nil)
+	c.call("launch", LaunchArguments{Program: path, StopOnEntry: true}, nil)
+	c.call("configurationDone", nil, nil)
+
+	var stopped struct {
+		Reason string `json:"reason"`
+	}
+	c.event("stopped", &stopped)
+	if stopped.Reason != "entry" {
+		t.Fatalf("expected to stop on entry. got=%q", stopped.Reason)
+	}
+
+	c.call("terminate", nil, nil)
+
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	c.event("exited", &exited)
+	if exited.ExitCode != 1 {
+		t.Errorf("terminated program should exit with an error. got=%d", exited.ExitCode)
+	}
+}
+
+func TestLaunchPolicy(t *testing.T) {
+	t.Setenv("MONKEY_DAP_TEST", "granted")
+	path := filepath.Join(t.TempDir(), "env.mk")
+	if err := os.WriteFile(path, []byte(`getenv("MONKEY_DAP_TEST")`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args     LaunchArguments
+		expected string
+	}{
+		{LaunchArguments{Program: path, NoDebug: true}, "permission denied"},
+		{LaunchArguments{Program: path, NoDebug: true, AllowEnv: []string{"MONKEY_DAP_TEST"}}, "granted\n"},
+		{LaunchArguments{Program: path, NoDebug: true, AllowAll: true}, "granted\n"},
+	}
+
+	for _, tt := range tests {
+		c := newClient(t)
+		c.call("initialize", nil, nil)
+		c.call("launch", tt.args, nil)
+		c.call("configurationDone", nil, nil)
+
+		var output struct {
+			Output string `json:"output"`
+		}
+		c.event("output", &output)
+		if !strings.Contains(output.Output, tt.expected) {
+			t.Errorf("%+v: wrong program output. expected %q in %q", tt.args, tt.expected, output.Output)
+		}
+		c.event("terminated", nil)
+	}
+}
+
+func TestLaunchErrors(t *testing.T) {
+	c := newClient(t)
+
+	seq := c.request("launch", LaunchArguments{Program: filepath.Join(t.TempDir(), "missing.mk")})
+	m := c.next(func(m message) bool { return m.Type == "response" && m.RequestSeq == seq }, "launch response")
+	if m.Success {
+		t.Errorf("launching a missing file should fail")
+	}
+
+	seq = c.request("configurationDone", nil)
+	m = c.next(func(m message) bool { return m.Type == "response" && m.RequestSeq == seq }, "configurationDone response")
+	if m.Success {
+		t.Errorf("configurationDone without a program should fail")
+	}
+}
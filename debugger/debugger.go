@@ -0,0 +1,240 @@
+package debugger
+
+import (
+	"context"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/resolver"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Action tells the debugger how to resume after a stop
+type Action int
+
+const (
+	// Continue runs until the next breakpoint
+	Continue Action = iota
+	// StepIn stops at the next statement, entering called functions
+	StepIn
+	// StepOver stops at the next statement in the current function or its callers
+	StepOver
+	// StepOut stops at the next statement after the current function returns
+	StepOut
+	// Quit aborts the program
+	Quit
+)
+
+// Reasons execution can stop for
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+)
+
+// Stop describes where execution is paused
+type Stop struct {
+	Reason string
+	Line   int
+	Node   ast.Node
+	Env    *object.Environment
+}
+
+// StackFrame is a level of the call stack while paused
+type StackFrame struct {
+	Name string
+	Line int
+	Env  *object.Environment
+}
+
+// Debugger evaluates a program statement by statement, pausing at breakpoints
+// and steps and handing control to OnStop
+type Debugger struct {
+	Interpreter *evaluator.Interpreter
+	OnStop      func(Stop) Action
+	StopOnEntry bool
+
+	// mu guards the fields front-ends may change while the program runs
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	pause       bool
+
+	action  Action
+	depth   int // call depth when the current step began
+	started bool
+	quit    bool
+
+	// the line and environment of the statement being evaluated at each call depth
+	lines []int
+	envs  []*object.Environment
+
+	lastLine, lastDepth int
+}
+
+// New returns a Debugger that calls onStop whenever execution pauses
+func New(onStop func(Stop) Action) *Debugger {
+	d := &Debugger{
+		Interpreter: evaluator.New(),
+		OnStop:      onStop,
+		breakpoints: make(map[int]bool),
+	}
+	d.Interpreter.Hook = d.hook
+	return d
+}
+
+// Run evaluates program in env, a global environment, under the debugger
+func (d *Debugger) Run(program *ast.Program, env *object.Environment) object.Object {
+	resolver.Resolve(program)
+	d.action = Continue
+	if d.StopOnEntry {
+		d.action = StepIn
+	}
+	defer d.Interpreter.Close()
+	return d.Interpreter.EvalContext(context.Background(), program, env)
+}
+
+// SetBreakpoint pauses execution before statements starting on line
+func (d *Debugger) SetBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+}
+
+// ClearBreakpoint removes the breakpoint on line
+func (d *Debugger) ClearBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints, line)
+}
+
+// ClearBreakpoints removes every breakpoint
+func (d *Debugger) ClearBreakpoints() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = make(map[int]bool)
+}
+
+// Pause asks a running program to stop at its next statement. It may be
+// called from another goroutine.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pause = true
+}
+
+// Breakpoints returns the lines with breakpoints in order
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Stack returns the call stack while paused, innermost frame first
+func (d *Debugger) Stack() []StackFrame {
+	frames := d.Interpreter.Frames()
+
+	stack := []StackFrame{}
+	for depth := len(frames); depth >= 0 && depth < len(d.lines); depth-- {
+		name := "<program>"
+		if depth > 0 {
+			name = frames[depth-1].Name()
+		}
+		stack = append(stack, StackFrame{Name: name, Line: d.lines[depth], Env: d.envs[depth]})
+	}
+	return stack
+}
+
+func (d *Debugger) hook(node ast.Node, env *object.Environment) *object.Error {
+	if d.quit {
+		return &object.Error{Message: "debugging session terminated"}
+	}
+
+	switch node.(type) {
+	case *ast.LetStatement, *ast.ReturnStatement, *ast.ExpressionStatement:
+	default:
+		return nil
+	}
+
+	line := ast.TokenOf(node).Line
+	depth := d.Interpreter.Depth()
+	if depth < len(d.lines) {
+		d.lines, d.envs = d.lines[:depth+1], d.envs[:depth+1]
+		d.lines[depth], d.envs[depth] = line, env
+	} else {
+		d.lines, d.envs = append(d.lines, line), append(d.envs, env)
+	}
+
+	d.mu.Lock()
+	breakpoint, pause := d.breakpoints[line], d.pause
+	d.pause = false
+	d.mu.Unlock()
+
+	reason := ""
+	switch {
+	case pause:
+		reason = ReasonPause
+	case d.action == StepIn,
+		d.action == StepOver && depth <= d.depth,
+		d.action == StepOut && depth < d.depth:
+		reason = ReasonStep
+	case breakpoint && (line != d.lastLine || depth != d.lastDepth):
+		reason = ReasonBreakpoint
+	}
+	d.lastLine, d.lastDepth = line, depth
+
+	if reason == "" {
+		return nil
+	}
+	if !d.started && d.StopOnEntry {
+		reason = ReasonEntry
+	}
+	d.started = true
+
+	d.action = d.OnStop(Stop{Reason: reason, Line: line, Node: node, Env: env})
+	d.depth = depth
+	if d.action == Quit {
+		d.quit = true
+		return &object.Error{Message: "debugging session terminated"}
+	}
+
+	return nil
+}
+
+// Evaluate parses input and evaluates it in env without stopping at breakpoints.
+// Let statements in input bind in env, which is how paused programs are modified.
+// Input is not resolved, since env may belong to any call, so its identifiers
+// are looked up by name.
+func Evaluate(input string, env *object.Environment) (object.Object, []string) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, p.Errors()
+	}
+	return evaluator.Eval(program, env), nil
+}
+
+// Summarize renders a value on one line for display, eliding function bodies
+func Summarize(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nil"
+	case *object.Function:
+		params := []string{}
+		for _, p := range obj.Parameters {
+			params = append(params, p.String())
+		}
+		return "fn(" + strings.Join(params, ", ") + ") {...}"
+	default:
+		return obj.Inspect()
+	}
+}
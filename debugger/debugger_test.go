@@ -0,0 +1,129 @@
+package debugger
+
+import (
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/parser"
+	"reflect"
+	"testing"
+)
+
+const input = `let add = fn(a, b) {
+  let sum = a + b;
+  sum
+};
+let x = add(1, 2);
+let y = add(x, 3);
+y;`
+
+// script resumes with each action in turn, recording the lines it stopped on
+type script struct {
+	actions []Action
+	lines   []int
+	stops   []Stop
+	d       *Debugger
+	stacks  [][]StackFrame
+}
+
+func (s *script) onStop(stop Stop) Action {
+	s.lines = append(s.lines, stop.Line)
+	s.stops = append(s.stops, stop)
+	s.stacks = append(s.stacks, s.d.Stack())
+	if len(s.actions) == 0 {
+		return Continue
+	}
+	action := s.actions[0]
+	s.actions = s.actions[1:]
+	return action
+}
+
+func run(t *testing.T, s *script, entry bool, breakpoints ...int) object.Object {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	s.d = New(s.onStop)
+	s.d.StopOnEntry = entry
+	for _, line := range breakpoints {
+		s.d.SetBreakpoint(line)
+	}
+	return s.d.Run(program, object.NewEnvironment())
+}
+
+func TestStepping(t *testing.T) {
+	tests := []struct {
+		actions  []Action
+		expected []int
+	}{
+		{[]Action{StepIn, StepIn, StepIn, StepIn, StepIn}, []int{1, 5, 2, 3, 6, 2}},
+		{[]Action{StepOver, StepOver, StepOver, StepOver}, []int{1, 5, 6, 7}},
+		{[]Action{StepOver, StepIn, StepOut}, []int{1, 5, 2, 6}},
+		{[]Action{Continue}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		s := &script{actions: tt.actions}
+		evaluated := run(t, s, true)
+
+		if !reflect.DeepEqual(s.lines, tt.expected) {
+			t.Errorf("stopped on wrong lines for %v. want=%v, got=%v", tt.actions, tt.expected, s.lines)
+		}
+		if result, ok := evaluated.(*object.Integer); !ok || result.Value != 6 {
+			t.Errorf("program result wrong. got=%v", evaluated)
+		}
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	s := &script{}
+	run(t, s, false, 2, 7)
+
+	if !reflect.DeepEqual(s.lines, []int{2, 2, 7}) {
+		t.Fatalf("stopped on wrong lines. got=%v", s.lines)
+	}
+	if s.stops[0].Reason != ReasonBreakpoint {
+		t.Errorf("wrong stop reason. got=%q", s.stops[0].Reason)
+	}
+
+	stack := s.stacks[1]
+	if len(stack) != 2 || stack[0].Name != "add" || stack[0].Line != 2 || stack[1].Name != "<program>" || stack[1].Line != 6 {
+		t.Fatalf("wrong stack. got=%+v", stack)
+	}
+
+	a, _ := stack[0].Env.Get("a")
+	if a.Inspect() != "3" {
+		t.Errorf("wrong value for a in second call. got=%s", a.Inspect())
+	}
+}
+
+func TestQuit(t *testing.T) {
+	s := &script{actions: []Action{Quit}}
+	evaluated := run(t, s, true)
+
+	if err, ok := evaluated.(*object.Error); !ok || err.Message != "debugging session terminated" {
+		t.Errorf("expected termination error. got=%v", evaluated)
+	}
+}
+
+func TestModifyEnvironment(t *testing.T) {
+	s := &script{}
+	s.d = New(func(stop Stop) Action {
+		if stop.Line == 3 {
+			changed, errors := Evaluate("100", stop.Env)
+			if len(errors) != 0 || !stop.Env.Assign("sum", changed) {
+				t.Fatalf("could not assign sum")
+			}
+		}
+		return Continue
+	})
+	s.d.SetBreakpoint(3)
+
+	p := parser.New(lexer.New(input))
+	evaluated := s.d.Run(p.ParseProgram(), object.NewEnvironment())
+
+	if result, ok := evaluated.(*object.Integer); !ok || result.Value != 100 {
+		t.Errorf("assignment did not change the program's result. got=%v", evaluated)
+	}
+}
@@ -0,0 +1,160 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/object"
+)
+
+// namedArgument is an argument passed as name: value
+type namedArgument struct {
+	name  *ast.Identifier
+	value object.Object
+}
+
+// evalArguments evaluates the arguments of a call in order, putting the
+// elements of spread arrays in place of them and named arguments aside. It
+// returns an error instead if evaluating one fails.
+func (in *Interpreter) evalArguments(exps []ast.Expression, env *object.Environment) ([]object.Object, []namedArgument, object.Object) {
+	args := []object.Object{}
+	var named []namedArgument
+
+	for _, exp := range exps {
+		switch exp := exp.(type) {
+		case *ast.SpreadExpression:
+			value := in.Eval(exp.Value, env)
+			if isError(value) {
+				return nil, nil, value
+			}
+			arr, ok := value.(*object.Array)
+			if !ok {
+				return nil, nil, newError("spread operator not supported: %s", value.Type())
+			}
+			args = append(args, arr.Elements()...)
+
+		case *ast.NamedArgument:
+			value := in.Eval(exp.Value, env)
+			if isError(value) {
+				return nil, nil, value
+			}
+			named = append(named, namedArgument{name: exp.Name, value: value})
+
+		default:
+			value := in.Eval(exp, env)
+			if isError(value) {
+				return nil, nil, value
+			}
+			args = append(args, value)
+		}
+	}
+
+	return args, named, nil
+}
+
+// bindArguments binds the parameters of fn in env, the environment of a call
+// of it. Arguments go to the parameters in order and named ones to the
+// parameter of that name, the rest parameter collects those left over, and
+// parameters given nothing get their default, evaluated in env once the
+// parameters before them are bound.
+func (in *Interpreter) bindArguments(fn *object.Function, args []object.Object, named []namedArgument, env *object.Environment) object.Object {
+	fixed := len(fn.Parameters)
+	if fn.Variadic {
+		fixed--
+	}
+	got := len(args) + len(named)
+
+	values := make([]object.Object, len(fn.Parameters))
+	if len(args) > fixed {
+		if !fn.Variadic {
+			return newError("wrong number of arguments. got=%d, want=%s", got, wantArguments(fn))
+		}
+		if err := in.Allocate(len(args) - fixed); err != nil {
+			return err
+		}
+		rest := in.allocate(object.NewArray(args[fixed:]))
+		if isError(rest) {
+			return rest
+		}
+		values[fixed] = rest
+		args = args[:fixed]
+	} else if fn.Variadic {
+		values[fixed] = object.NewArray(nil)
+	}
+	copy(values, args)
+
+	for _, arg := range named {
+		i := fixed - 1
+		for i >= 0 && fn.Parameters[i].Value != arg.name.Value {
+			i--
+		}
+		switch {
+		case i < 0 && fn.Variadic && fn.Parameters[fixed].Value == arg.name.Value:
+			return newError("the rest parameter %s cannot be named", arg.name.Value)
+		case i < 0:
+			return newError("no parameter named %s", arg.name.Value)
+		case values[i] != nil:
+			return newError("more than one value for parameter %s", arg.name.Value)
+		}
+		values[i] = arg.value
+	}
+
+	for i, param := range fn.Parameters[:fixed] {
+		if values[i] == nil && fn.Default(i) == nil {
+			return newError("wrong number of arguments. got=%d, want=%s: missing %s", got, wantArguments(fn), param.Value)
+		}
+	}
+
+	for i, param := range fn.Parameters {
+		value := values[i]
+		if value == nil {
+			value = in.Eval(fn.Default(i), env)
+			if isError(value) {
+				return value
+			}
+		}
+		bind(env, param, value)
+
+		if pattern := fn.Pattern(i); pattern != nil {
+			if err := in.destructure(pattern, value, env); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// wantArguments describes the number of arguments fn takes
+func wantArguments(fn *object.Function) string {
+	required, fixed := len(fn.Parameters), len(fn.Parameters)
+	if fn.Variadic {
+		fixed--
+	}
+	for i := range fn.Parameters {
+		if fn.Default(i) != nil || i == fixed {
+			required = i
+			break
+		}
+	}
+
+	switch {
+	case fn.Variadic:
+		return fmt.Sprintf("at least %d", required)
+	case required < fixed:
+		return fmt.Sprintf("%d to %d", required, fixed)
+	}
+	return fmt.Sprint(fixed)
+}
+
+// wantBuiltinArguments describes the number of arguments fn takes
+func wantBuiltinArguments(fn *object.Builtin) string {
+	switch {
+	case fn.MaxArgs < 0:
+		return fmt.Sprintf("want at least %d", fn.MinArgs)
+	case fn.MaxArgs == fn.MinArgs:
+		return fmt.Sprintf("want=%d", fn.MinArgs)
+	case fn.MaxArgs == fn.MinArgs+1:
+		return fmt.Sprintf("want=%d or %d", fn.MinArgs, fn.MaxArgs)
+	}
+	return fmt.Sprintf("want=%d to %d", fn.MinArgs, fn.MaxArgs)
+}
@@ -1,96 +1,242 @@
 package evaluator
 
-import "monkey_interpreter/object"
+import (
+	"math/rand"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/object"
+	"os"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
 
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
 			switch arg := args[0].(type) {
 			case *object.Array:
-				return &object.Integer{Value: int64(len(arg.Elements))}
+				return &object.Integer{Value: int64(arg.Len())}
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(arg.Len())}
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
 		},
 	},
 	"first": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
+		MinArgs: 1, MaxArgs: 1, Reuses: true,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("argument to `first` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
-			if len(arr.Elements) > 0 {
-				return arr.Elements[0]
+			if arr.Len() > 0 {
+				return arr.Get(0)
 			}
 
 			return NULL
 		},
 	},
 	"last": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
+		MinArgs: 1, MaxArgs: 1, Reuses: true,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("argument to `last` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
+			length := arr.Len()
 			if length > 0 {
-				return arr.Elements[length-1]
+				return arr.Get(length - 1)
 			}
 
 			return NULL
 		},
 	},
 	"rest": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("argument to `rest` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
+			length := arr.Len()
 			if length > 0 {
-				newElements := make([]object.Object, length-1, length-1)
-				copy(newElements, arr.Elements[1:length])
-				return &object.Array{Elements: newElements}
+				return arr.Slice(1, length)
 			}
 
 			return NULL
 		},
 	},
-	"push": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
+	"slice": &object.Builtin{
+		MinArgs: 2, MaxArgs: 3,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			var end object.Object
+			if len(args) == 3 {
+				end = args[2]
 			}
+			return sliceObject(args[0], args[1], end)
+		},
+	},
+	"push": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
+			return arr.Push(args[1])
+		},
+	},
+	"puts": &object.Builtin{
+		MinArgs: 0, MaxArgs: -1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return write(host, "\n")
+			}
+			return write(host, inspectAll(args, "\n")+"\n")
+		},
+	},
+	"print": &object.Builtin{
+		MinArgs: 0, MaxArgs: -1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			return write(host, inspectAll(args, " "))
+		},
+	},
+	"format": &object.Builtin{
+		MinArgs: 1, MaxArgs: -1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			format, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `format` must be STRING, got %s", args[0].Type())
+			}
+			return formatString(format.Value, args[1:])
+		},
+	},
+	"printf": &object.Builtin{
+		MinArgs: 1, MaxArgs: -1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			format, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `printf` must be STRING, got %s", args[0].Type())
+			}
 
-			newElements := make([]object.Object, length+1, length+1)
-			copy(newElements, arr.Elements)
-			newElements[length] = args[1]
+			formatted := formatString(format.Value, args[1:])
+			if isError(formatted) {
+				return formatted
+			}
+			return write(host, formatted.(*object.String).Value)
+		},
+	},
+	"import": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			path, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `import` must be STRING, got %s", args[0].Type())
+			}
 
-			return &object.Array{Elements: newElements}
+			return host.Import(path.Value)
 		},
 	},
+	"getenv": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			name, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `getenv` must be STRING, got %s", args[0].Type())
+			}
+			if err := host.Check(object.ENV, name.Value); err != nil {
+				return err
+			}
+
+			value, ok := os.LookupEnv(name.Value)
+			if !ok {
+				return NULL
+			}
+			return &object.String{Value: value}
+		},
+	},
+	"now": &object.Builtin{
+		MinArgs: 0, MaxArgs: 0,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			if err := host.Check(object.CLOCK, ""); err != nil {
+				return err
+			}
+
+			return &object.Integer{Value: time.Now().UnixNano() / int64(time.Millisecond)}
+		},
+	},
+	"random": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			n, ok := smallInt(args[0])
+			if !ok || n <= 0 {
+				return newError("argument to `random` must be a positive INTEGER, got %s", args[0].Inspect())
+			}
+			if err := host.Check(object.RANDOM, ""); err != nil {
+				return err
+			}
+
+			return &object.Integer{Value: rand.Int63n(n)}
+		},
+	},
+}
+
+// init merges the builtins defined in other files into builtins
+func init() {
+	for _, group := range []map[string]*object.Builtin{stringBuiltins, hashBuiltins, listBuiltins, iteratorBuiltins, fileBuiltins} {
+		for name, builtin := range group {
+			builtins[name] = builtin
+		}
+	}
+}
+
+// IsBuiltin reports whether name refers to a builtin function, native or from the
+// prelude, or to a builtin module
+func IsBuiltin(name string) bool {
+	_, ok := builtins[name]
+	_, module := builtinModules[name]
+	return ok || module || isPrelude(name)
+}
+
+// BuiltinArity returns the least and the most arguments the builtin function
+// name, native or from the prelude, takes, the most being -1 when there is no
+// limit. It returns false when name is not a builtin function.
+func BuiltinArity(name string) (min, max int, ok bool) {
+	if builtin, ok := builtins[name]; ok {
+		return builtin.MinArgs, builtin.MaxArgs, true
+	}
+	preludeOnce.Do(parsePrelude)
+	for _, let := range preludeLets {
+		if let.Name.Value != name {
+			continue
+		}
+		fn := let.Value.(*ast.FunctionLiteral)
+		if fn.Variadic {
+			return fn.Required(), -1, true
+		}
+		return fn.Required(), len(fn.Parameters), true
+	}
+	return 0, 0, false
+}
+
+// BuiltinNames returns the names of every builtin function, native or from the
+// prelude, and of every builtin module, in sorted order
+func BuiltinNames() []string {
+	names := PreludeNames()
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range builtinModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -1,9 +1,13 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"monkey_interpreter/ast"
 	"monkey_interpreter/object"
+	"os"
+	"strings"
 )
 
 var (
@@ -15,117 +19,277 @@ var (
 	FALSE = &object.Boolean{Value: false}
 )
 
-// Eval function evaluates the code
+// Hook is called before a node is evaluated. Returning an error aborts the evaluation with it.
+type Hook func(node ast.Node, env *object.Environment) *object.Error
+
+// Frame is a function call in progress, or a generator running its body
+// until the next yield
+type Frame struct {
+	Function *object.Function
+	Call     *ast.CallExpression // nil when the call did not come from source
+	Env      *object.Environment
+
+	generator *generatorRun // set when the frame runs the body of a generator
+}
+
+// Name describes the called function for stack traces
+func (f *Frame) Name() string {
+	if f.Call != nil {
+		return f.Call.Function.String()
+	}
+	return "fn"
+}
+
+// Interpreter evaluates programs and holds the state of a run
+type Interpreter struct {
+	Hook   Hook
+	Limits Limits
+	Policy Policy
+	// Out receives what programs print
+	Out io.Writer
+	// FS is the filesystem file builtins use
+	FS object.FileSystem
+	// File is the path of the source being evaluated, which relative imports start from.
+	// Calls switch it to the source the called function was defined in.
+	File string
+	// SearchPath lists the directories searched for imports not found beside File
+	SearchPath []string
+
+	frames     []*Frame
+	modules    map[string]*object.Module
+	prelude    *object.Environment
+	loading    []string               // paths of the modules being evaluated, outermost first
+	generators map[*generatorRun]bool // started and not finished yet, see Close
+
+	// the budget of the current run, see EvalContext
+	ctx             context.Context
+	steps, elements int
+	aborted         *object.Error
+}
+
+// New returns an Interpreter printing to standard output and using the
+// operating system's files, limited to DefaultMaxDepth nested calls, with
+// nothing else attached
+func New() *Interpreter {
+	return &Interpreter{Out: os.Stdout, FS: OSFileSystem{}, Limits: Limits{MaxDepth: DefaultMaxDepth}}
+}
+
+// Output implements object.Host
+func (in *Interpreter) Output() io.Writer {
+	if in.Out == nil {
+		return io.Discard
+	}
+	return in.Out
+}
+
+// Files implements object.Host
+func (in *Interpreter) Files() object.FileSystem {
+	if in.FS == nil {
+		return OSFileSystem{}
+	}
+	return in.FS
+}
+
+// Call implements object.Host
+func (in *Interpreter) Call(fn object.Object, args ...object.Object) object.Object {
+	return in.applyFunction(fn, args, nil, nil)
+}
+
+// Frames returns the calls currently being evaluated, outermost first
+func (in *Interpreter) Frames() []*Frame {
+	frames := make([]*Frame, len(in.frames))
+	copy(frames, in.frames)
+	return frames
+}
+
+// Depth returns the number of calls currently being evaluated
+func (in *Interpreter) Depth() int {
+	return len(in.frames)
+}
+
+// Eval function evaluates the code with a fresh Interpreter
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	in := New()
+	defer in.Close()
+	return in.EvalContext(context.Background(), node, env)
+}
+
+// Eval evaluates node in env
+func (in *Interpreter) Eval(node ast.Node, env *object.Environment) object.Object {
+	if node != nil {
+		if err := in.step(); err != nil {
+			return err
+		}
+	}
+	if in.Hook != nil && node != nil {
+		if err := in.Hook(node, env); err != nil {
+			return err
+		}
+	}
+
 	switch node := node.(type) {
 
 	case *ast.Program:
-		return evalProgram(node, env)
+		return in.evalProgram(node, env)
 
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return in.Eval(node.Expression, env)
 
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return object.NewBigInteger(node.Big)
+		}
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := in.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
+		left := in.Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
 
-		right := Eval(node.Right, env)
+		right := in.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return in.allocate(evalInfixExpression(node.Operator, left, right))
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return in.evalBlockStatement(node, env)
 
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return in.evalIfExpression(node, env)
+
+	case *ast.ForExpression:
+		return in.evalForExpression(node, env)
 
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
+		val := in.Eval(node.ReturnValue, env)
 		if isError(val) {
 			return val
 		}
 
 		return &object.ReturnValue{Value: val}
+	case *ast.YieldStatement:
+		val := in.Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		return in.yield(val)
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
+		val := in.Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
 
-		env.Set(node.Name.Value, val)
+		if node.Pattern != nil {
+			return in.destructure(node.Pattern, val, env)
+		}
+		bind(env, node.Name, val)
 
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return in.evalIdentifier(node, env)
 
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		return &object.Function{
+			Parameters: params,
+			Patterns:   node.ParameterPatterns,
+			Defaults:   node.ParameterDefaults,
+			Variadic:   node.Variadic,
+			Generator:  node.Generator,
+			Env:        env,
+			Body:       body,
+			Locals:     node.Locals,
+			File:       in.File,
+		}
 
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
+		function := in.Eval(node.Function, env)
 		if isError(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
+		args, named, err := in.evalArguments(node.Arguments, env)
+		if err != nil {
+			return err
 		}
 
-		return applyFunction(function, args)
+		return in.applyFunction(function, args, named, node)
 
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.InterpolatedString:
+		return in.evalInterpolatedString(node, env)
+
 	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
+		elements := in.evalExpressions(node.Elements, env)
 
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
-		return &object.Array{Elements: elements}
+		return in.allocate(object.NewArray(elements))
 
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
+		left := in.Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		index := Eval(node.Index, env)
+		index := in.Eval(node.Index, env)
 		if isError(index) {
 			return index
 		}
 		return evalIndexExpression(left, index)
 
+	case *ast.SliceExpression:
+		return in.evalSliceExpression(node, env)
+
+	case *ast.MemberExpression:
+		obj := in.Eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return evalMemberExpression(obj, node.Property.Value)
+
 	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
+		return in.evalHashLiteral(node, env)
+
+	case *ast.MatchExpression:
+		return in.evalMatchExpression(node, env)
 	}
 
 	return nil
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+// bind sets the name a let or pattern binds in env
+func bind(env *object.Environment, name *ast.Identifier, val object.Object) {
+	if name.Local {
+		env.SetSlot(name.Index, val)
+	} else {
+		env.Set(name.Value, val)
+	}
+}
+
+func (in *Interpreter) evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range program.Statements {
-		result = Eval(statement, env)
+		result = in.Eval(statement, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -138,11 +302,11 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+func (in *Interpreter) evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range block.Statements {
-		result = Eval(statement, env)
+		result = in.Eval(statement, env)
 
 		if result != nil {
 			rt := result.Type()
@@ -186,68 +350,35 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 	}
 }
 
-func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
-	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
-}
-
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
-	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalNumberInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
 		return nativeBoolToBooleanObject(left != right)
+	case operator == "in":
+		return evalInExpression(left, right)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
-	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
-		return evalStringInfixExpression(operator, left, right)
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
-	leftVal := left.(*object.Integer).Value
-	rightVal := right.(*object.Integer).Value
-
-	switch operator {
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
-	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
-	case "<":
-		return nativeBoolToBooleanObject(leftVal < rightVal)
-	case ">":
-		return nativeBoolToBooleanObject(leftVal > rightVal)
-	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
-	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
-	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
-}
-
-func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
+func (in *Interpreter) evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := in.Eval(ie.Condition, env)
 	if isError(condition) {
 		return condition
 	}
 
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
+		return in.Eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return in.Eval(ie.Alternative, env)
 	} else {
 		return NULL
 	}
@@ -277,7 +408,15 @@ func isError(obj object.Object) bool {
 	return false
 }
 
-func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+func (in *Interpreter) evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if node.Local {
+		if val, ok := env.GetSlot(node.Depth, node.Index); ok {
+			return val
+		}
+		// the slot is not set yet, so the name may still be bound further out
+	} else {
+		env = env.Up(node.Depth)
+	}
 	if val, ok := env.Get(node.Value); ok {
 		return val
 	}
@@ -286,14 +425,23 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
+	if isPrelude(node.Value) {
+		val, _ := in.preludeEnv().Get(node.Value)
+		return val
+	}
+
+	if module, ok := builtinModules[node.Value]; ok {
+		return module
+	}
+
 	return newError("identifier not found: " + node.Value)
 }
 
-func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+func (in *Interpreter) evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
 	for _, e := range exps {
-		evaluated := Eval(e, env)
+		evaluated := in.Eval(e, env)
 		if isError(evaluated) {
 			return []object.Object{evaluated}
 		}
@@ -303,32 +451,47 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// applyFunction calls fn with args and the named arguments named; call is
+// the expression it was called from, if any
+func (in *Interpreter) applyFunction(fn object.Object, args []object.Object, named []namedArgument, call *ast.CallExpression) object.Object {
 	switch fn := fn.(type) {
 
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
+		if err := in.enter(); err != nil {
+			return err
+		}
+		extendedEnv := object.NewLocalEnvironment(fn.Env, fn.Locals)
+		outerFile := in.File
+		in.File, in.frames = fn.File, append(in.frames, &Frame{Function: fn, Call: call, Env: extendedEnv})
+		evaluated := in.bindArguments(fn, args, named, extendedEnv)
+		if evaluated == nil {
+			if fn.Generator {
+				evaluated = &generator{in: in, fn: fn, env: extendedEnv, call: call}
+			} else {
+				evaluated = in.Eval(fn.Body, extendedEnv)
+			}
+		}
+		in.File, in.frames = outerFile, in.frames[:len(in.frames)-1]
 		return unwrapReturnValue(evaluated)
 
 	case *object.Builtin:
-		return fn.Fn(args...)
+		if len(named) > 0 {
+			return newError("builtin functions take no named arguments, got %s", named[0].name.Value)
+		}
+		if len(args) < fn.MinArgs || fn.MaxArgs >= 0 && len(args) > fn.MaxArgs {
+			return newError("wrong number of arguments. got=%d, %s", len(args), wantBuiltinArguments(fn))
+		}
+		result := fn.Fn(in, args...)
+		if fn.Reuses || isArgument(result, args) {
+			return result
+		}
+		return in.allocate(result)
 
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnclosedEnvironment(fn.Env)
-
-	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
-	}
-
-	return env
-}
-
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
 		return returnValue.Value
@@ -337,44 +500,81 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	return obj
 }
 
-func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+// evalInterpolatedString joins the text of node with what each of its
+// expressions evaluates to, as Inspect shows it
+func (in *Interpreter) evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+	for _, part := range node.Parts {
+		value := in.Eval(part, env)
+		if isError(value) {
+			return value
+		}
+		out.WriteString(value.Inspect())
 	}
+	return in.allocate(&object.String{Value: out.String()})
+}
 
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
-	return &object.String{Value: leftVal + rightVal}
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	case "in":
+		return nativeBoolToBooleanObject(strings.Contains(rightVal, leftVal))
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.MODULE_OBJ:
+		return evalModuleIndexExpression(left, index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
 }
 
+// evalMemberExpression looks up obj.name, which is obj["name"] for hashes and modules
+func evalMemberExpression(obj object.Object, name string) object.Object {
+	switch obj.Type() {
+	case object.HASH_OBJ:
+		return evalHashIndexExpression(obj, &object.String{Value: name})
+	case object.MODULE_OBJ:
+		return evalModuleIndexExpression(obj, &object.String{Value: name})
+	default:
+		return newError("member access not supported: %s", obj.Type())
+	}
+}
+
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
-
-	if idx < 0 || idx > max {
+	idx, ok := sequenceIndex(index, arrayObject.Len())
+	if !ok {
 		return NULL
 	}
 
-	return arrayObject.Elements[idx]
+	return arrayObject.Get(idx)
 }
 
-func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+func (in *Interpreter) evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, keyNode := range node.Keys {
+		valueNode := node.Pairs[keyNode]
+		key := in.Eval(keyNode, env)
 		if isError(key) {
 			return key
 		}
@@ -384,16 +584,15 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := in.Eval(valueNode, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey.HashKey(), object.HashPair{Key: key, Value: value})
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return in.allocate(hash)
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
@@ -404,7 +603,7 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(key.HashKey())
 	if !ok {
 		return NULL
 	}
@@ -1,10 +1,18 @@
 package evaluator
 
 import (
+	"bytes"
+	"context"
 	"monkey_interpreter/lexer"
 	"monkey_interpreter/object"
 	"monkey_interpreter/parser"
+	"monkey_interpreter/resolver"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -39,6 +47,7 @@ func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
+	resolver.Resolve(program)
 	env := object.NewEnvironment()
 
 	return Eval(program, env)
@@ -345,13 +354,13 @@ func TestArrayLiterals(t *testing.T) {
 		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	if len(result.Elements) != 3 {
-		t.Fatalf("array has wrong num of elements. got=%d", len(result.Elements))
+	if result.Len() != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d", result.Len())
 	}
 
-	testIntegerObject(t, result.Elements[0], 1)
-	testIntegerObject(t, result.Elements[1], 4)
-	testIntegerObject(t, result.Elements[2], 6)
+	testIntegerObject(t, result.Get(0), 1)
+	testIntegerObject(t, result.Get(1), 4)
+	testIntegerObject(t, result.Get(2), 6)
 }
 
 func TestArrayIndexExpressions(t *testing.T) {
@@ -407,12 +416,12 @@ false: 6
 		FALSE.HashKey():                            6,
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
 
 	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+		pair, ok := result.Get(expectedKey)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
 		}
@@ -420,3 +429,1200 @@ false: 6
 		testIntegerObject(t, pair.Value, expectedValue)
 	}
 }
+
+func TestLimits(t *testing.T) {
+	fib := `let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; `
+	forever := `let f = fn(x) { f(x) }; f(1)`
+	grow := `let grow = fn(arr) { grow(push(arr, len(arr))) }; grow([])`
+
+	tests := []struct {
+		input    string
+		limits   Limits
+		expected string
+	}{
+		{forever, Limits{MaxSteps: 1000}, StepLimitExceeded},
+		{forever, Limits{MaxDepth: 100}, DepthLimitExceeded},
+		{forever, New().Limits, DepthLimitExceeded},
+		{grow, Limits{MaxElements: 1000}, ElementLimitExceeded},
+		{`let s = "ab"; s + s + s + s`, Limits{MaxElements: 10}, ElementLimitExceeded},
+		{fib + "fib(30)", Limits{Timeout: 10 * time.Millisecond}, Timeout},
+		{`collect(range(1e18))`, Limits{MaxSteps: 1000}, StepLimitExceeded},
+		{`collect(range(1e18))`, Limits{MaxElements: 1000}, ElementLimitExceeded},
+		{`repeat("ab", 5000000000)`, Limits{MaxElements: 1000}, ElementLimitExceeded},
+		{`math.pow(2, 100000)`, Limits{MaxElements: 1000}, ElementLimitExceeded},
+		{`flatMap([1, 2], fn(x) { collect(range(600)) })`, Limits{MaxElements: 1000}, ElementLimitExceeded},
+		{`let f = fn() { for (x in f()) { yield x } }; collect(f())`, Limits{MaxDepth: 50}, DepthLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		in := New()
+		in.Limits = tt.limits
+
+		evaluated := in.EvalContext(context.Background(), program, object.NewEnvironment())
+		err, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if err.Code != tt.expected || !IsAborted(err) {
+			t.Errorf("%q: wrong error code. expected=%q, got=%q (%s)", tt.input, tt.expected, err.Code, err.Message)
+		}
+	}
+}
+
+func TestLimitsAllowFinishedRuns(t *testing.T) {
+	in := New()
+	in.Limits = Limits{MaxSteps: 10000, MaxDepth: 20, MaxElements: 100, Timeout: time.Second}
+	program := parser.New(lexer.New(`let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(10)`)).ParseProgram()
+
+	for i := 0; i < 3; i++ {
+		testIntegerObject(t, in.EvalContext(context.Background(), program, object.NewEnvironment()), 55)
+	}
+}
+
+func TestLimitsCountNewObjectsOnly(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let a = collect(range(60)); len(first([a])) + len(last([a]))`, "120"},
+		{`let a = collect(range(60)); len(reduce([1, 2, 3], a, fn(acc, x) { acc }))`, "60"},
+		{`let a = collect(range(60)); len(find([a], fn(x) { true }))`, "60"},
+		{`let n = 100000000000000000000000000000000000000; for (i in range(10)) { math.abs(n); math.max(n, 1) }; n > 0`, "true"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		resolver.Resolve(program)
+		in := New()
+		in.Limits = Limits{MaxElements: 100}
+
+		evaluated := in.EvalContext(context.Background(), program, object.NewEnvironment())
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	program := parser.New(lexer.New(`1 + 2`)).ParseProgram()
+	evaluated := New().EvalContext(ctx, program, object.NewEnvironment())
+	if err, ok := evaluated.(*object.Error); !ok || err.Code != Canceled {
+		t.Errorf("expected a canceled error. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if IsAborted(testEval(`1 + true`)) {
+		t.Errorf("errors raised by the program should not count as aborted")
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	t.Setenv("MONKEY_TEST_VAR", "banana")
+
+	tests := []struct {
+		input    string
+		policy   Policy
+		expected interface{} // PermissionDenied or the expected result
+	}{
+		{`getenv("MONKEY_TEST_VAR")`, Policy{}, PermissionDenied},
+		{`getenv("MONKEY_TEST_VAR")`, Policy{Env: []string{"HOME"}}, PermissionDenied},
+		{`getenv("MONKEY_TEST_VAR")`, Policy{Env: []string{"MONKEY_TEST_VAR"}}, "banana"},
+		{`getenv("MONKEY_TEST_VAR")`, AllowAll, "banana"},
+		{`now() > 0`, Policy{}, PermissionDenied},
+		{`now() > 0`, Policy{Clock: true}, true},
+		{`random(1)`, Policy{}, PermissionDenied},
+		{`random(1)`, Policy{Random: true}, 0},
+		{`len("free")`, Policy{}, 4},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.Policy = tt.policy
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if expected == PermissionDenied {
+				err, ok := evaluated.(*object.Error)
+				if !ok || err.Code != PermissionDenied {
+					t.Errorf("%q: expected permission to be denied. got=%T(%+v)", tt.input, evaluated, evaluated)
+				}
+				continue
+			}
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("%q: expected %q. got=%T(%+v)", tt.input, expected, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestPolicyPaths(t *testing.T) {
+	policy := Policy{Read: []string{"data", "/etc/hosts"}, Write: []string{"*"}}
+
+	tests := []struct {
+		capability object.Capability
+		path       string
+		expected   bool
+	}{
+		{object.READ, "data", true},
+		{object.READ, "./data/input.txt", true},
+		{object.READ, "data/../data/x", true},
+		{object.READ, "data/../secret", false},
+		{object.READ, "database", false},
+		{object.READ, "/etc/hosts", true},
+		{object.READ, "/etc/passwd", false},
+		{object.WRITE, "/anywhere", true},
+		{object.ENV, "HOME", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Allows(tt.capability, tt.path); got != tt.expected {
+			t.Errorf("Allows(%s, %q) wrong. expected=%t, got=%t", tt.capability, tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestPolicySymlinks(t *testing.T) {
+	dir := t.TempDir()
+	allowed, outside := filepath.Join(dir, "allowed"), filepath.Join(dir, "outside")
+	for _, d := range []string{allowed, outside, filepath.Join(allowed, "sub")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"escape":   outside,
+		"dangling": filepath.Join(outside, "new.txt"),
+		"inside":   "sub",
+		"loop":     "loop",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(allowed, name)); err != nil {
+			t.Skipf("cannot create symbolic links: %v", err)
+		}
+	}
+	policy := Policy{Read: []string{allowed}, Write: []string{allowed}}
+
+	tests := []struct {
+		capability object.Capability
+		path       string
+		expected   bool
+	}{
+		{object.READ, filepath.Join(allowed, "sub", "file.txt"), true},
+		{object.READ, filepath.Join(allowed, "inside", "file.txt"), true},
+		{object.READ, filepath.Join(allowed, "escape"), false},
+		{object.READ, filepath.Join(allowed, "escape", "secret.txt"), false},
+		{object.WRITE, filepath.Join(allowed, "escape", "new", "file.txt"), false},
+		{object.WRITE, filepath.Join(allowed, "dangling"), false},
+		{object.WRITE, filepath.Join(allowed, "new.txt"), true},
+		{object.WRITE, filepath.Join(allowed, "loop"), false},
+		{object.READ, allowed + "/escape/../outside/secret.txt", false},
+		{object.READ, allowed + "/escape/../allowed/sub", true},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Allows(tt.capability, tt.path); got != tt.expected {
+			t.Errorf("Allows(%s, %q) wrong. expected=%t, got=%t", tt.capability, tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lib/math.mk":    `let u = import("util"); let double = fn(x) { x * 2 }; let loads = u["loads"];`,
+		"vendor/util.mk": `let greeting = "hi"; let loads = 1;`,
+		"cycle/a.mk":     `let b = import("./b");`,
+		"cycle/b.mk":     `let a = import("./a");`,
+		"broken.mk":      `let x = ;`,
+		"fails.mk":       `let x = 1 + true;`,
+		"lib/lazy.mk":    `let load = fn() { import("./b")["b"] }; let later = fn() { fn() { import("./b")["b"] } };`,
+		"lib/b.mk":       `let b = "beside lazy";`,
+	}
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, b := filepath.Join(dir, "cycle", "a.mk"), filepath.Join(dir, "cycle", "b.mk")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let m = import("./lib/math"); let u = import("util"); [m["double"](21), u["greeting"], m["loads"]]`, "[42, hi, 1]"},
+		{`import("./vendor/util") == import("vendor/util.mk")`, "true"},
+		{`import("./cycle/a")`, "ERROR: import cycle: " + a + " -> " + b + " -> " + a},
+		{`import("./broken")`, "ERROR: cannot import \"./broken\": "},
+		{`import("./fails")`, "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{`import("./util")`, "ERROR: module not found: ./util.mk"},
+		{`let lazy = import("./lib/lazy"); lazy["load"]()`, "beside lazy"},
+		{`let lazy = import("./lib/lazy"); let f = lazy["later"](); [f(), import("./lib/b")["b"]]`, "[beside lazy, beside lazy]"},
+		{`let lazy = import("./lib/lazy"); lazy["load"](); import("./b")`, "ERROR: module not found: ./b.mk"},
+		{`import("util")["nope"]`, "ERROR: module " + filepath.Join(dir, "vendor", "util.mk") + " has no member nope"},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.File = filepath.Join(dir, "main.mk")
+		in.SearchPath = []string{filepath.Join(dir, "vendor")}
+		in.Policy = Policy{Read: []string{dir}}
+
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if evaluated == nil || !strings.HasPrefix(evaluated.Inspect(), tt.expected) {
+			t.Errorf("%s: wrong result. expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestImportPolicy(t *testing.T) {
+	dir := t.TempDir()
+	for name, source := range map[string]string{"secret.mk": `let key = "hunter2";`, "vendor/util.mk": `let loads = 1;`} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		policy   Policy
+		expected string
+	}{
+		{`import("./secret")["key"]`, Policy{}, PermissionDenied},
+		{`import("` + filepath.Join(dir, "secret") + `")["key"]`, Policy{}, PermissionDenied},
+		{`import("./secret")["key"]`, Policy{Read: []string{filepath.Join(dir, "vendor")}}, PermissionDenied},
+		{`import("./missing")`, Policy{}, PermissionDenied},
+		{`import("util")["loads"]`, Policy{Read: []string{filepath.Join(dir, "vendor")}}, "1"},
+		{`import("./secret")["key"]`, Policy{Read: []string{dir}}, "hunter2"},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.File = filepath.Join(dir, "main.mk")
+		in.SearchPath = []string{filepath.Join(dir, "vendor")}
+		in.Policy = tt.policy
+
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if err, ok := evaluated.(*object.Error); ok {
+			if err.Code != tt.expected {
+				t.Errorf("%s: wrong error. expected code %s, got=%s (%s)", tt.input, tt.expected, err.Code, err.Message)
+			}
+			continue
+		}
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestPrelude(t *testing.T) {
+	source, err := os.ReadFile(filepath.Join("testdata", "prelude_test.mk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("prelude tests do not parse: %v", p.Errors())
+	}
+
+	in := New()
+	env := object.NewEnvironment()
+	if evaluated := in.Eval(program, env); isError(evaluated) {
+		t.Fatalf("prelude tests failed to load: %s", evaluated.Inspect())
+	}
+
+	ran := 0
+	for _, name := range env.Names() {
+		if !strings.HasPrefix(name, "test") {
+			continue
+		}
+		fn, _ := env.Get(name)
+		if result := in.Call(fn); result != TRUE {
+			t.Errorf("%s failed. got=%v", name, result)
+		}
+		ran++
+	}
+	if ran == 0 {
+		t.Errorf("no prelude tests found")
+	}
+
+	for _, name := range PreludeNames() {
+		if !IsBuiltin(name) {
+			t.Errorf("%s is in the prelude but not reported as a builtin", name)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`puts("hello", 1, [true])`, "hello\n1\n[true]\n"},
+		{`puts()`, "\n"},
+		{`print("a", 1); print("b")`, "a 1b"},
+		{`printf("%d-%s|%5d|%-4s|%05d|%x\n", 1, "two", 3, "ab", -42, 255)`, "1-two|    3|ab  |-0042|ff\n"},
+		{`printf("%.2f %6.1f %t %q %v %v 100%%", 3, 2, false, "hi", [1, "a"], {"k": 1})`, `3.00    2.0 false "hi" [1, a] {k: 1} 100%`},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		in := New()
+		in.Out, in.Policy = &out, Policy{Output: true}
+
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if evaluated != NULL {
+			t.Errorf("%s: printing should return null. got=%v", tt.input, evaluated)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%s: wrong output. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	in := New()
+	in.Out = &out
+	evaluated := in.Eval(parser.New(lexer.New(`puts("hidden")`)).ParseProgram(), object.NewEnvironment())
+	if err, ok := evaluated.(*object.Error); !ok || err.Code != PermissionDenied || out.Len() != 0 {
+		t.Errorf("printing without the output capability should be denied. got=%v, output=%q", evaluated, out.String())
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`format("%s is %d", "x", 5)`, "x is 5"},
+		{`format("%3v|%-3v|", true, 1)`, "true|1  |"},
+		{`format("none")`, "none"},
+		{`format("%d")`, `ERROR: format "%d" is missing an argument for %d`},
+		{`format("%d", 1, 2)`, `ERROR: format "%d" has 1 arguments too many`},
+		{`format("%d", "1")`, "ERROR: %d in format needs INTEGER, got STRING"},
+		{`format("%s", 1)`, "ERROR: %s in format needs STRING, got INTEGER"},
+		{`format("%y", 1)`, "ERROR: unknown verb %y in format"},
+		{`format("50%")`, `ERROR: format "50%" ends in an incomplete verb`},
+		{`format(1)`, "ERROR: first argument to `format` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`len(split("héllo", ""))`, "5"},
+		{`join(["a", 1, true], "-")`, "a-1-true"},
+		{`join([], ",")`, ""},
+		{`trim("  hi \n")`, "hi"},
+		{`upper("ñandú")`, "ÑANDÚ"},
+		{`lower("ÀB")`, "àb"},
+		{`contains("monkey", "key")`, "true"},
+		{`contains("monkey", "ape")`, "false"},
+		{`startsWith("monkey", "mon")`, "true"},
+		{`endsWith("monkey", "mon")`, "false"},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`indexOf("héllo", "l")`, "2"},
+		{`indexOf("hello", "z")`, "-1"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", -1)`, "ERROR: second argument to `repeat` must be a non-negative INTEGER, got -1"},
+		{`repeat("ab", 9223372036854775807)`, "ERROR: result of `repeat` is too long"},
+		{`repeat("", 9223372036854775807)`, ""},
+		{`chars("añb")`, "[a, ñ, b]"},
+		{`substr("héllo", 1, 3)`, "éll"},
+		{`substr("héllo", 3)`, "lo"},
+		{`substr("héllo", 4, 10)`, "o"},
+		{`substr("héllo", 9)`, ""},
+		{`substr("héllo", -1)`, "ERROR: start of `substr` must be a non-negative INTEGER, got -1"},
+		{`len("héllo")`, "5"},
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[5]`, "null"},
+		{`"héllo"[-1]`, "o"},
+		{`upper(1)`, "ERROR: arguments to `upper` must be STRING, got INTEGER"},
+		{`split("a")`, "ERROR: wrong number of arguments. got=1, want=2"},
+		{`"a" + "b" == "ab"`, "true"},
+		{`"a" != "a"`, "false"},
+		{`"a" < "b"`, "ERROR: unknown operator: STRING < STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestHashBuiltins(t *testing.T) {
+	config := `let config = {"name": "monkey", "port": 80, 1: "one", true: [1]}; `
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{config + `keys(config)`, "[name, port, 1, true]"},
+		{config + `values(config)`, "[monkey, 80, one, [1]]"},
+		{config + `items(config)`, "[[name, monkey], [port, 80], [1, one], [true, [1]]]"},
+		{config + `config`, "{name: monkey, port: 80, 1: one, true: [1]}"},
+		{config + `len(config)`, "4"},
+		{config + `has(config, "port")`, "true"},
+		{config + `has(config, "host")`, "false"},
+		{config + `"name" in config`, "true"},
+		{config + `2 in config`, "false"},
+		{`2 in [1, 2, 3]`, "true"},
+		{`"b" in ["a", "b"]`, "true"},
+		{`[] in [[]]`, "false"},
+		{`"onk" in "monkey"`, "true"},
+		{`1 in 2`, "ERROR: unknown operator: INTEGER in INTEGER"},
+		{`fn() {} in {}`, "ERROR: unusable as hash key: FUNCTION"},
+		{config + `set(config, "port", 8080)`, "{name: monkey, port: 8080, 1: one, true: [1]}"},
+		{config + `set(config, "host", "localhost")`, "{name: monkey, port: 80, 1: one, true: [1], host: localhost}"},
+		{config + `let updated = set(config, "port", 1); config["port"]`, "80"},
+		{config + `delete(config, "port")`, "{name: monkey, 1: one, true: [1]}"},
+		{config + `delete(config, "missing")`, "{name: monkey, port: 80, 1: one, true: [1]}"},
+		{config + `let smaller = delete(config, "name"); len(config)`, "4"},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, "{a: 1, b: 3, c: 4}"},
+		{`{"b": 1, "a": 2, "b": 3}`, "{b: 3, a: 2}"},
+		{`keys([])`, "ERROR: first argument to `keys` must be HASH, got ARRAY"},
+		{`merge({}, [])`, "ERROR: arguments to `merge` must be HASH, got ARRAY"},
+		{`set({}, [], 1)`, "ERROR: unusable as hash key: ARRAY"},
+		{`map(items({"a": 1, "b": 2}), fn(pair) { pair[0] + "=" + format("%d", pair[1]) })`, "[a=1, b=2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestListBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], fn(x) { x * x })`, "[1, 4, 9]"},
+		{`map(["a", "b"], upper)`, "[A, B]"},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, "[3, 4]"},
+		{`reduce([1, 2, 3], 0, fn(acc, x) { acc + x })`, "6"},
+		{`reduce([], "start", fn(acc, x) { x })`, "start"},
+		{`find([1, 2, 3, 4], fn(x) { x > 1 })`, "2"},
+		{`find([1, 2], fn(x) { x > 5 })`, "null"},
+		{`flatMap([1, 2, 3], fn(x) { [x, x * 10] })`, "[1, 10, 2, 20, 3, 30]"},
+		{`flatMap([[1], 2], fn(x) { x })`, "[1, 2]"},
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort(["pear", "apple", "fig"])`, "[apple, fig, pear]"},
+		{`sort([3, 1, 2], fn(a, b) { a > b })`, "[3, 2, 1]"},
+		{`let a = [2, 1]; let b = sort(a); a`, "[2, 1]"},
+		{`sort([[2, "b"], [1, "x"], [2, "a"]], fn(a, b) { a[0] < b[0] })`, "[[1, x], [2, b], [2, a]]"},
+		{`sort([1, "a"])`, "ERROR: cannot sort STRING and INTEGER without a comparator"},
+		{`sort([1, 2], fn(a, b) { 1 })`, "ERROR: comparator passed to `sort` must return BOOLEAN, got INTEGER"},
+		{`map([1, 2], fn(x) { x + true })`, "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{`map([1], fn(x, y) { x })`, "ERROR: wrong number of arguments. got=1, want=2: missing y"},
+		{`map({}, fn(x) { x })`, "ERROR: first argument to `map` must be ARRAY or ITERATOR, got HASH"},
+		{`filter([1], 5)`, "ERROR: not a function: INTEGER"},
+		{`sortBy([[3, "c"], [1, "a"]], first)`, "[[1, a], [3, c]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`9223372036854775807 + 1`, "9223372036854775808"},
+		{`-9223372036854775807 - 2`, "-9223372036854775809"},
+		{`-9223372036854775808 == -9223372036854775807 - 1`, "true"},
+		{`100000000000000000000 + 1`, "100000000000000000001"},
+		{`4294967296 * 4294967296`, "18446744073709551616"},
+		{`(9223372036854775807 + 1) - 1`, "9223372036854775807"},
+		{`-(-9223372036854775807 - 1)`, "9223372036854775808"},
+		{`(-9223372036854775807 - 1) / -1`, "9223372036854775808"},
+		{`let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(25)`, "15511210043330985984000000"},
+		{`9223372036854775807 * 3 > 9223372036854775807`, "true"},
+		{`9223372036854775807 + 1 == 4611686018427387904 * 2`, "true"},
+		{`{9223372036854775807 + 1: "big"}[9223372036854775807 + 1]`, "big"},
+		{`1.5 + 2.25`, "3.75"},
+		{`1 / 2`, "0"},
+		{`1 / 2.0`, "0.5"},
+		{`2.0 * 3`, "6.0"},
+		{`-1.5`, "-1.5"},
+		{`0.1 + 0.2 > 0.3`, "true"},
+		{`1 == 1.0`, "true"},
+		{`2 < 2.5`, "true"},
+		{`{1.5: "x"}[1.5]`, "x"},
+		{`{1: "a"}[1.0]`, "a"},
+		{`{2.0: "b"}[2]`, "b"},
+		{`{-0.0: "zero"}[0]`, "zero"},
+		{`{100000000000000000000: "big"}[100000000000000000000.0]`, "big"},
+		{`{1.5: "x"}[1]`, "null"},
+		{`len({1: "a", 1.0: "b"})`, "1"},
+		{`sort([3, 1.5, 2])`, "[1.5, 2, 3]"},
+		{`format("%d %x %.2f", 9223372036854775807 + 1, 255, 1.005)`, "9223372036854775808 ff 1.00"},
+		{`5 / 0`, "ERROR: division by zero"},
+		{`1.5 + true`, "ERROR: type mismatch: FLOAT + BOOLEAN"},
+		{`"ab"[9223372036854775807 + 1]`, "null"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestMath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`math.abs(-3)`, "3"},
+		{`math.abs(-2.5)`, "2.5"},
+		{`math.abs(-9223372036854775807 - 1)`, "9223372036854775808"},
+		{`math.min(3, 1, 2)`, "1"},
+		{`math.max(3, 4.5, 2)`, "4.5"},
+		{`math.pow(2, 10)`, "1024"},
+		{`math.pow(2, 100)`, "1267650600228229401496703205376"},
+		{`math.pow(2, -1)`, "0.5"},
+		{`math.pow(4, 0.5)`, "2.0"},
+		{`math.pow(-1, 10000000001)`, "-1"},
+		{`math.pow(2, 9223372036854775807)`, "ERROR: exponent too large: 9223372036854775807"},
+		{`math.pow(2, 10000000000)`, "ERROR: exponent too large: 10000000000"},
+		{`math.pow(3, 100000000)`, "ERROR: exponent too large: 100000000"},
+		{`math.pow(2, 16777216)`, "ERROR: exponent too large: 16777216"},
+		{`math.pow(2, 4000000) > 0`, "true"},
+		{`math.sqrt(16)`, "4.0"},
+		{`math.floor(2.7)`, "2"},
+		{`math.ceil(2.1)`, "3"},
+		{`math.round(-2.5)`, "-3"},
+		{`math.round(100000000000000000000.4)`, "100000000000000000000"},
+		{`math.floor(7)`, "7"},
+		{`math.sin(0)`, "0.0"},
+		{`math.cos(math.pi)`, "-1.0"},
+		{`math.log(math.e)`, "1.0"},
+		{`math["pi"] > 3.14`, "true"},
+		{`import("math").sqrt(4)`, "2.0"},
+		{`let h = {"x": 1}; h.x`, "1"},
+		{`let h = {"x": 1}; h.y`, "null"},
+		{`math.nope`, "ERROR: module math has no member nope"},
+		{`math.sqrt("4")`, "ERROR: arguments to `sqrt` must be INTEGER or FLOAT, got STRING"},
+		{`math.min()`, "ERROR: wrong number of arguments. got=0, want at least 1"},
+		{`math.floor(math.sqrt(-1))`, "ERROR: cannot floor NaN to an INTEGER"},
+		{`[1].length`, "ERROR: member access not supported: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json.parse("{\"b\": [1, 2.5, true, null], \"a\": \"x\"}")`, "{b: [1, 2.5, true, null], a: x}"},
+		{`json.parse("{\"b\": 1, \"a\": 2}")["a"]`, "2"},
+		{`json.parse("123456789012345678901234567890")`, "123456789012345678901234567890"},
+		{`json.parse("1e3")`, "1000.0"},
+		{`json.parse("\"caf\\u00e9\"")`, "café"},
+		{`json.stringify({"b": [1, 2.5, true, first([])], "a": "x"})`, `{"b":[1,2.5,true,null],"a":"x"}`},
+		{`json.stringify({"a": [1, 2]}, 2)`, "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{`json.stringify([], "\t")`, "[]"},
+		{`json.stringify("<a & \"b\">")`, `"<a & \"b\">"`},
+		{`json.stringify(3.0)`, "3.0"},
+		{`json.stringify(math.pow(10, 30))`, "1000000000000000000000000000000"},
+		{`json.stringify([fn(x) { x }])`, "ERROR: cannot encode FUNCTION as JSON"},
+		{`json.stringify({1: 2})`, "ERROR: cannot encode INTEGER hash key as JSON"},
+		{`json.stringify(math.sqrt(-1))`, "ERROR: cannot encode NaN as JSON"},
+		{`json.stringify(1, true)`, "ERROR: indent passed to `stringify` must be STRING or INTEGER, got BOOLEAN"},
+		{`json.parse("{\"a\": }")`, "ERROR: invalid JSON: missing value after object key"},
+		{`json.parse("[1, 2")`, "ERROR: invalid JSON: unexpected end of JSON input"},
+		{`json.parse("")`, "ERROR: invalid JSON: unexpected end of JSON input"},
+		{`json.parse("1 2")`, "ERROR: invalid JSON: unexpected data after top-level value"},
+		{`json.parse(1)`, "ERROR: arguments to `parse` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	documents := []string{
+		`{"name":"monkey","tags":["a","b"],"nested":{"z":1,"a":[true,false,null]},"pi":3.14,"big":12345678901234567890123}`,
+		`[1,-2,0.5,"é\n",{}]`,
+		`"plain"`,
+		`null`,
+	}
+
+	for _, doc := range documents {
+		env := object.NewEnvironment()
+		env.Set("doc", &object.String{Value: doc})
+		evaluated := Eval(parser.New(lexer.New(`json.stringify(json.parse(doc))`)).ParseProgram(), env)
+		if evaluated.Inspect() != doc {
+			t.Errorf("round trip changed the document.\nwant=%s\ngot= %s", doc, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFileBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`readFile("data/a.txt")`, "alpha\n"},
+		{`readLines("data/lines.txt")`, "[one, two, , three]"},
+		{`readLines("data/empty.txt")`, "[]"},
+		{`writeFile("out.txt", "x"); appendFile("out.txt", "y"); readFile("out.txt")`, "xy"},
+		{`writeFile("data/a.txt", "new"); readFile("data/a.txt")`, "new"},
+		{`listDir("data")`, "[a.txt, empty.txt, lines.txt, sub]"},
+		{`listDir(".")`, "[data]"},
+		{`[exists("data/a.txt"), exists("data/sub"), exists("nope")]`, "[true, true, false]"},
+		{`mkdir("x/y/z"); [exists("x/y"), listDir("x/y")]`, "[true, [z]]"},
+		{`remove("data/sub/b.txt"); remove("data/sub"); exists("data/sub")`, "false"},
+		{`readFile("nope.txt")`, "ERROR: readFile: open nope.txt: file does not exist"},
+		{`readFile("data")`, "ERROR: readFile: read data: is a directory"},
+		{`writeFile("missing/dir.txt", "")`, "ERROR: writeFile: open missing/dir.txt: file does not exist"},
+		{`remove("data")`, "ERROR: remove: remove data: directory not empty"},
+		{`mkdir("data/a.txt/b")`, "ERROR: mkdir: mkdir data/a.txt: not a directory"},
+		{`listDir("data/a.txt")`, "ERROR: listDir: readdirent data/a.txt: not a directory"},
+		{`writeFile("out.txt", 1)`, "ERROR: second argument to `writeFile` must be STRING, got INTEGER"},
+		{`readFile(1)`, "ERROR: first argument to `readFile` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.Policy = AllowAll
+		in.FS = NewMemoryFileSystem(map[string]string{
+			"data/a.txt":     "alpha\n",
+			"data/lines.txt": "one\r\ntwo\n\nthree\n",
+			"data/empty.txt": "",
+			"data/sub/b.txt": "beta",
+		})
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestFileBuiltinsPolicy(t *testing.T) {
+	tests := []struct {
+		input  string
+		policy Policy
+		denied bool
+	}{
+		{`readFile("in/a.txt")`, Policy{}, true},
+		{`readFile("in/a.txt")`, Policy{Read: []string{"in"}}, false},
+		{`exists("out")`, Policy{Write: []string{"out"}}, true},
+		{`writeFile("out/b.txt", "")`, Policy{Read: []string{"*"}}, true},
+		{`writeFile("out/b.txt", "")`, Policy{Write: []string{"out"}}, false},
+		{`appendFile("in/a.txt", "")`, Policy{Write: []string{"out"}}, true},
+		{`mkdir("in/new")`, Policy{Read: []string{"in"}}, true},
+		{`remove("in/a.txt")`, Policy{Write: []string{"in"}}, false},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		in.Policy = tt.policy
+		in.FS = NewMemoryFileSystem(map[string]string{"in/a.txt": "a", "out/.keep": ""})
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+
+		err, isErr := evaluated.(*object.Error)
+		if denied := isErr && err.Code == PermissionDenied; denied != tt.denied {
+			t.Errorf("%s with %+v: expected denied=%t. got=%v", tt.input, tt.policy, tt.denied, evaluated)
+		} else if !tt.denied && isErr {
+			t.Errorf("%s: unexpected error %s", tt.input, err.Message)
+		}
+	}
+}
+
+func TestOSFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	in := New()
+	in.Policy = Policy{Read: []string{dir}, Write: []string{dir}}
+
+	env := object.NewEnvironment()
+	env.Set("dir", &object.String{Value: dir})
+	input := `mkdir(dir + "/notes");
+writeFile(dir + "/notes/a.txt", "first\n");
+appendFile(dir + "/notes/a.txt", "second\n");
+let lines = readLines(dir + "/notes/a.txt");
+remove(dir + "/notes/a.txt");
+[lines, listDir(dir + "/notes"), exists(dir + "/notes")]`
+	evaluated := in.Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+
+	if evaluated.Inspect() != "[[first, second], [], true]" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+
+	missing := in.Eval(parser.New(lexer.New(`readFile(dir + "/missing")`)).ParseProgram(), env)
+	if err, ok := missing.(*object.Error); !ok || !strings.Contains(err.Message, "no such file") {
+		t.Errorf("expected a missing file error. got=%v", missing)
+	}
+}
+
+func TestSlicing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2, 3][-1]`, "3"},
+		{`[1, 2, 3][-3]`, "1"},
+		{`[1, 2, 3][-4]`, "null"},
+		{`"héllo"[-4]`, "é"},
+		{`[1, 2, 3, 4][1:3]`, "[2, 3]"},
+		{`[1, 2, 3, 4][:2]`, "[1, 2]"},
+		{`[1, 2, 3, 4][2:]`, "[3, 4]"},
+		{`[1, 2, 3, 4][:]`, "[1, 2, 3, 4]"},
+		{`[1, 2, 3, 4][-2:]`, "[3, 4]"},
+		{`[1, 2, 3, 4][:-1]`, "[1, 2, 3]"},
+		{`[1, 2, 3, 4][3:1]`, "[]"},
+		{`[1, 2, 3, 4][-10:10]`, "[1, 2, 3, 4]"},
+		{`[1, 2][0:9223372036854775807 * 2]`, "[1, 2]"},
+		{`let a = [1, 2, 3]; let b = push(a[:2], 9); [a, b]`, "[[1, 2, 3], [1, 2, 9]]"},
+		{`"héllo"[1:4]`, "éll"},
+		{`"héllo"[-3:]`, "llo"},
+		{`slice([1, 2, 3], 1)`, "[2, 3]"},
+		{`slice("abc", 0, -1)`, "ab"},
+		{`slice([1, 2, 3], -2, 3)`, "[2, 3]"},
+		{`[1, 2]["a":]`, "ERROR: slice bounds must be INTEGER, got STRING"},
+		{`{"a": 1}[0:1]`, "ERROR: slice operator not supported: HASH"},
+		{`slice([1])`, "ERROR: wrong number of arguments. got=1, want=2 or 3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func BenchmarkBuildList(b *testing.B) {
+	program := parser.New(lexer.New(`
+let build = fn(n, acc) { if (n == 0) { acc } else { build(n - 1, push(acc, n)) } };
+let drain = fn(arr, total) { if (len(arr) == 0) { total } else { drain(rest(arr), total + first(arr)) } };
+drain(build(2000, []), 0);
+`)).ParseProgram()
+
+	for i := 0; i < b.N; i++ {
+		if result := Eval(program, object.NewEnvironment()); result.Inspect() != "2001000" {
+			b.Fatalf("wrong result. got=%s", result.Inspect())
+		}
+	}
+}
+
+func TestResolvedScopes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x = 1; let f = fn() { x }; let x = 2; f()`, "2"},
+		{`let f = fn(x) { fn(y) { x + y } }; f(1)(2)`, "3"},
+		{`let f = fn(x) { let x = x * 10; x }; f(2)`, "20"},
+		{`let f = fn() { let g = fn() { y }; let y = 5; g() }; f()`, "5"},
+		{`let y = "global"; let f = fn() { let before = y; let y = "local"; [before, y] }; f()`, "[global, local]"},
+		{`let f = fn() { if (true) { let z = 3 }; z }; f()`, "3"},
+		{`let counter = fn(n) { if (n == 0) { 0 } else { 1 + counter(n - 1) } }; counter(50)`, "50"},
+		{`let apply = fn(f, x) { f(x) }; let x = 10; apply(fn(y) { x + y }, 1)`, "11"},
+		{`let f = fn() { missing }; f()`, "ERROR: identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		for _, resolve := range []bool{true, false} {
+			program := parser.New(lexer.New(tt.input)).ParseProgram()
+			if resolve {
+				resolver.Resolve(program)
+			}
+			evaluated := Eval(program, object.NewEnvironment())
+			if evaluated == nil || evaluated.Inspect() != tt.expected {
+				t.Errorf("%s (resolved=%t): expected=%q, got=%v", tt.input, resolve, tt.expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestResolvedIncrementalPrograms(t *testing.T) {
+	in := New()
+	env := object.NewEnvironment()
+
+	var evaluated object.Object
+	for _, line := range []string{
+		`let add = fn(a, b) { a + b + offset };`,
+		`let offset = 100;`,
+		`let twice = fn(x) { add(x, x) };`,
+		`twice(1)`,
+	} {
+		program := parser.New(lexer.New(line)).ParseProgram()
+		resolver.Resolve(program)
+		evaluated = in.Eval(program, env)
+	}
+
+	testIntegerObject(t, evaluated, 102)
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (2) { 1 => "one", 2 => "two", _ => "many" }`, "two"},
+		{`match (7) { 1 => "one", _ => "many" }`, "many"},
+		{`match (-1) { -1 => "minus one", _ => "other" }`, "minus one"},
+		{`match ("b") { "a" => 1, "b" => 2 }`, "2"},
+		{`match (1) { 1.0 => "equal", _ => "not equal" }`, "equal"},
+		{`match (5) { n => n * 2 }`, "10"},
+		{`match ([1, 2, 3]) { [] => 0, [head, ...tail] => [head, tail] }`, "[1, [2, 3]]"},
+		{`match ([1]) { [a, b] => "two", [a] => "one" }`, "one"},
+		{`match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }`, "6"},
+		{`match ([1, 2]) { [1, x] => x, _ => 0 }`, "2"},
+		{`match ("ab") { [a, ...b] => a, _ => "not an array" }`, "not an array"},
+		{`match ({"name": "ann", "age": 3}) { {"name": n, age} => [n, age] }`, "[ann, 3]"},
+		{`match ({"kind": "circle", "r": 2}) { {"kind": "square", "side": s} => s * s, {"kind": "circle", "r": r} => 3 * r * r }`, "12"},
+		{`match ({"a": 1}) { {"b": b} => b, _ => "no b" }`, "no b"},
+		{`match (5) { x if x < 0 => "negative", x if x > 0 => "positive", _ => "zero" }`, "positive"},
+		{`let x = 1; match (2) { x => x }`, "2"},
+		{`let x = 1; match (2) { x => x }; x`, "1"},
+		{`let f = fn(v) { let x = 1; let y = match (v) { x => x * 10 }; [x, y] }; f(2)`, "[1, 20]"},
+		{`let x = 1; let g = match (2) { x => fn() { x } }; [g(), x]`, "[2, 1]"},
+		{`match (2) { x => if (true) { let inner = x; inner } }; inner`, "ERROR: identifier not found: inner"},
+		{`let x = 1; match ([2, [3]]) { [x, [y]] => match (y) { x => x } + x }`, "5"},
+		{`let x = 10; match (5) { x if x > 100 => 1, _ => x }`, "10"},
+		{`let x = 10; match (5) { x if x > 100 => 1, _ => 0 }; x`, "10"},
+		{`match (5) { y if y > 100 => 1, _ => 0 }; y`, "ERROR: identifier not found: y"},
+		{`let f = fn(v) { let x = 1; match (v) { [x, y] if x > 100 => x, _ => x } }; f([200, 2])`, "200"},
+		{`let f = fn(v) { let x = 1; match (v) { [x, y] if x > 100 => 0, _ => x } }; f([5, 2])`, "1"},
+		{`let f = fn(xs) { match (xs) { [] => 0, [x, ...rest] => x + f(rest) } }; f([1, 2, 3, 4])`, "10"},
+		{`let f = fn(v) { let before = match (v) { [a] => a }; let a = 9; [before, a] }; f([1])`, "[1, 9]"},
+		{`match (5) { 1 => "one" }`, "ERROR: no pattern matches 5"},
+		{`match ([1, 2]) { [a] => a }`, "ERROR: no pattern matches [1, 2]"},
+		{`match (1) { x if missing => x }`, "ERROR: identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let [a, b] = [1, 2]; a + b`, "3"},
+		{`let [head, ...tail] = [1, 2, 3]; [head, tail]`, "[1, [2, 3]]"},
+		{`let {name, age} = {"name": "ann", "age": 3}; [name, age]`, "[ann, 3]"},
+		{`let {"pos": [x, y], "id": id} = {"id": 7, "pos": [1, 2]}; [id, x, y]`, "[7, 1, 2]"},
+		{`let [a, [b, c]] = [1, [2, 3]]; a + b + c`, "6"},
+		{`let [a, b = 10] = [1]; a + b`, "11"},
+		{`let [a, b = 10] = [1, 2]; a + b`, "3"},
+		{`let {size = "m", color} = {"color": "red"}; size + " " + color`, "m red"},
+		{`let [_, second] = ["x", "y"]; second`, "y"},
+		{`let swap = fn([a, b]) { [b, a] }; swap([1, 2])`, "[2, 1]"},
+		{`let greet = fn({name, greeting = "hi"}) { greeting + " " + name }; greet({"name": "bo"})`, "hi bo"},
+		{`let f = fn(x, [a, ...rest]) { x + a + len(rest) }; f(10, [1, 2, 3])`, "13"},
+		{`let sum = fn(xs) { if (len(xs) == 0) { 0 } else { let [x, ...rest] = xs; x + sum(rest) } }; sum([1, 2, 3])`, "6"},
+		{`let outer = 5; let f = fn([a = outer]) { a }; f([])`, "5"},
+		{`let f = fn(n) { fn([a]) { a + n } }; f(1)([2])`, "3"},
+		{`let [a, b] = [1]; a`, "ERROR: cannot destructure [1]: [1] has 1 elements, want 2"},
+		{`let [a, b] = [1, 2, 3]; a`, "ERROR: cannot destructure [1, 2, 3]: [1, 2, 3] has 3 elements, want 2"},
+		{`let [a, b = 1] = []; a`, "ERROR: cannot destructure []: [] has 0 elements, want 1 to 2"},
+		{`let [a, b, ...c] = [1]; a`, "ERROR: cannot destructure [1]: [1] has 1 elements, want at least 2"},
+		{`let [a] = "a"; a`, "ERROR: cannot destructure a: a is not an array"},
+		{`let {name} = {"age": 1}; name`, "ERROR: cannot destructure {age: 1}: {age: 1} has no key name"},
+		{`let {name} = 5; name`, "ERROR: cannot destructure 5: 5 is not a hash"},
+		{`let [1, a] = [2, 3]; a`, "ERROR: cannot destructure [2, 3]: 2 is not 1"},
+		{`let [a = missing] = []; a`, "ERROR: identifier not found: missing"},
+		{`let f = fn([a, b]) { a }; f([1])`, "ERROR: cannot destructure [1]: [1] has 1 elements, want 2"},
+		{`let f = fn([a, b]) { a }; f([1], 2)`, "ERROR: wrong number of arguments. got=2, want=1"},
+		{`fn([a]) { a }`, "fn([a]) {\na\n}"},
+	}
+
+	for _, tt := range tests {
+		for _, resolve := range []bool{true, false} {
+			program := parser.New(lexer.New(tt.input)).ParseProgram()
+			if resolve {
+				resolver.Resolve(program)
+			}
+			evaluated := Eval(program, object.NewEnvironment())
+			if evaluated == nil || evaluated.Inspect() != tt.expected {
+				t.Errorf("%s (resolved=%t): expected=%q, got=%v", tt.input, resolve, tt.expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestParametersAndArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let f = fn(x, y = 10) { x + y }; [f(1), f(1, 2)]`, "[11, 3]"},
+		{`let f = fn(x, y = x * 2) { y }; f(4)`, "8"},
+		{`let n = 0; let f = fn(x = n + 1) { x }; f()`, "1"},
+		{`let f = fn(first, ...others) { [first, others] }; [f(1), f(1, 2, 3)]`, "[[1, []], [1, [2, 3]]]"},
+		{`let f = fn(...all) { len(all) }; f()`, "0"},
+		{`let add = fn(a, b, c) { a + b + c }; add(...[1, 2, 3])`, "6"},
+		{`let add = fn(a, b, c) { a + b + c }; add(1, ...[2], ...[], 3)`, "6"},
+		{`push(...[[1], 2])`, "[1, 2]"},
+		{`let f = fn(x, y = 2, z = 3) { [x, y, z] }; f(1, z: 30)`, "[1, 2, 30]"},
+		{`let f = fn(x, y) { x - y }; f(y: 1, x: 10)`, "9"},
+		{`let f = fn(a, ...rest) { [a, rest] }; f(1, ...[2, 3])`, "[1, [2, 3]]"},
+		{`let f = fn([a, b] = [1, 2], c = a + b) { c }; f()`, "3"},
+		{`map([1, 2], fn(x, scale = 10) { x * scale })`, "[10, 20]"},
+		{`let f = fn(x, y = 1) { x }; f`, "fn(x, y = 1) {\nx\n}"},
+		{`let f = fn(...xs) { xs }; f`, "fn(...xs) {\nxs\n}"},
+		{`let f = fn(x, y) { x }; f(1)`, "ERROR: wrong number of arguments. got=1, want=2: missing y"},
+		{`let f = fn(x, y) { x }; f(y: 1)`, "ERROR: wrong number of arguments. got=1, want=2: missing x"},
+		{`let f = fn(x, y = 1) { x }; f()`, "ERROR: wrong number of arguments. got=0, want=1 to 2: missing x"},
+		{`let f = fn(x, y = 1) { x }; f(1, 2, 3)`, "ERROR: wrong number of arguments. got=3, want=1 to 2"},
+		{`let f = fn(x, ...y) { x }; f()`, "ERROR: wrong number of arguments. got=0, want=at least 1: missing x"},
+		{`let f = fn(x) { x }; f(1, ...[2])`, "ERROR: wrong number of arguments. got=2, want=1"},
+		{`let f = fn(x) { x }; f(z: 1)`, "ERROR: no parameter named z"},
+		{`let f = fn(x) { x }; f(1, x: 2)`, "ERROR: more than one value for parameter x"},
+		{`let f = fn(x, ...y) { x }; f(1, y: 2)`, "ERROR: the rest parameter y cannot be named"},
+		{`let f = fn(x = missing) { x }; f()`, "ERROR: identifier not found: missing"},
+		{`let f = fn(x) { x }; f(...5)`, "ERROR: spread operator not supported: INTEGER"},
+		{`len(x: "a")`, "ERROR: builtin functions take no named arguments, got x"},
+	}
+
+	for _, tt := range tests {
+		for _, resolve := range []bool{true, false} {
+			program := parser.New(lexer.New(tt.input)).ParseProgram()
+			if resolve {
+				resolver.Resolve(program)
+			}
+			evaluated := Eval(program, object.NewEnvironment())
+			if evaluated == nil || evaluated.Inspect() != tt.expected {
+				t.Errorf("%s (resolved=%t): expected=%q, got=%v", tt.input, resolve, tt.expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "ann"; let age = 3; "hello ${name}, you are ${age + 1}"`, "hello ann, you are 4"},
+		{`"${1}${2.5}${true}${[1, "a"]}"`, "12.5true[1, a]"},
+		{`let f = fn(x) { "<${x}>" }; f(f("a"))`, "<<a>>"},
+		{`"nested ${"inner ${1 + 1}"}"`, "nested inner 2"},
+		{`"${ {"k": "v"}["k"] }"`, "v"},
+		{`"cost: \${5}"`, "cost: ${5}"},
+		{`"${if (false) { 1 }}"`, "null"},
+		{`"a ${missing} b"`, "ERROR: identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestForExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let sum = 0; for (x in [1, 2, 3]) { let sum = sum + x }; sum`, "6"},
+		{`let out = []; for (c in "hé!") { let out = push(out, c) }; out`, "[h, é, !]"},
+		{`let out = []; for (k in {"a": 1, "b": 2}) { let out = push(out, k) }; out`, "[a, b]"},
+		{`let out = []; for ([k, v] in items({"a": 1})) { let out = push(out, k + "=" + json.stringify(v)) }; out`, "[a=1]"},
+		{`let out = []; for (i in range(3)) { let out = push(out, i) }; out`, "[0, 1, 2]"},
+		{`for (x in []) { x }`, "null"},
+		{`let f = fn(xs) { for (x in xs) { if (x > 1) { return x } }; 0 }; [f([1, 5, 7]), f([1])]`, "[5, 0]"},
+		{`for (x in 5) { x }`, "ERROR: cannot iterate over INTEGER"},
+		{`for ([a, b] in [[1, 2], [3]]) { a }`, "ERROR: cannot destructure [3]: [3] has 1 elements, want 2"},
+		{`for (x in [1, 2]) { x + true }`, "ERROR: type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestBuiltinArity(t *testing.T) {
+	all := map[string]*object.Builtin{}
+	for name, builtin := range builtins {
+		all[name] = builtin
+	}
+	for name, module := range builtinModules {
+		for _, member := range module.Env.Names() {
+			if builtin, ok := module.Env.Get(member); ok {
+				if builtin, ok := builtin.(*object.Builtin); ok {
+					all[name+"."+member] = builtin
+				}
+			}
+		}
+	}
+
+	for name, builtin := range all {
+		if builtin.MaxArgs == 0 && name != "now" || builtin.MaxArgs >= 0 && builtin.MaxArgs < builtin.MinArgs {
+			t.Errorf("%s takes %d to %d arguments", name, builtin.MinArgs, builtin.MaxArgs)
+		}
+	}
+
+	for _, name := range []string{"len", "zip", "puts"} {
+		if _, _, ok := BuiltinArity(name); !ok {
+			t.Errorf("no arity for %s", name)
+		}
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let g = fn() { yield 1; yield 2; yield 3 }; collect(g())`, "[1, 2, 3]"},
+		{`let g = fn(n) { for (i in range(n)) { yield i * i } }; collect(g(4))`, "[0, 1, 4, 9]"},
+		{`let nat = fn() { let n = 0; for (_ in range(1e18)) { yield n; let n = n + 1 } }; take(nat(), 5)`, "[0, 1, 2, 3, 4]"},
+		{`let g = fn() { yield 1; yield 2; yield 3 }; let it = g(); [take(it, 2), collect(it), collect(it)]`, "[[1, 2], [3], []]"},
+		{`let g = fn() { yield 1; return 2; yield 3 }; collect(g())`, "[1]"},
+		{`let g = fn() { yield 1 }; g()`, "generator"},
+		{`let g = fn(a, b = a * 10) { yield a; yield b }; collect(g(1))`, "[1, 10]"},
+		{`let g = fn(a) { yield a }; g()`, "ERROR: wrong number of arguments. got=0, want=1: missing a"},
+		{`let g = fn() { yield 1; yield missing }; collect(g())`, "ERROR: identifier not found: missing"},
+		{`let g = fn() { yield 1; yield missing }; let it = g(); [collect(it)[0], collect(it)]`, "ERROR: identifier not found: missing"},
+		{`let g = fn() { yield collect(it) }; let it = g(); collect(it)`, "ERROR: generator is already running"},
+		{`let inner = fn() { yield 1; yield 2 }; let outer = fn() { for (x in inner()) { yield x; yield -x } }; collect(outer())`, "[1, -1, 2, -2]"},
+		{`let g = fn() { yield 1 }; let it = g(); let sum = 0; for (x in it) { let sum = sum + x }; for (x in it) { let sum = sum + 100 }; sum`, "1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestGeneratorsStopWithTheInterpreter(t *testing.T) {
+	before := runtime.NumGoroutine()
+	program := parser.New(lexer.New(`let g = fn() { yield 1; yield 2 }; let it = g(); take(it, 1)`)).ParseProgram()
+	resolver.Resolve(program)
+
+	in := New()
+	env := object.NewEnvironment()
+	for i := 0; i < 100; i++ {
+		in.EvalContext(context.Background(), program, env)
+	}
+
+	again := parser.New(lexer.New(`take(it, 1)`)).ParseProgram()
+	if evaluated := in.EvalContext(context.Background(), again, env); evaluated.Inspect() != "[2]" {
+		t.Errorf("expected a later run to resume the generator. got=%v", evaluated)
+	}
+	in.EvalContext(context.Background(), program, env)
+
+	in.Close()
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if running := runtime.NumGoroutine(); running > before {
+		t.Errorf("generator goroutines left running. before=%d, after=%d", before, running)
+	}
+
+	evaluated := in.EvalContext(context.Background(), again, env)
+	if err, ok := evaluated.(*object.Error); !ok || err.Message != "generator was stopped when the interpreter was closed" {
+		t.Errorf("expected an error resuming a stopped generator. got=%v", evaluated)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	isPrime := `let isPrime = fn(n) { let check = fn(d) { if (d * d > n) { true } else { if (n / d * d == n) { false } else { check(d + 1) } } }; if (n < 2) { false } else { check(2) } }; `
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`range(5)`, "range(0, 5)"},
+		{`collect(range(2, 5))`, "[2, 3, 4]"},
+		{`collect(range(5, 0, -2))`, "[5, 3, 1]"},
+		{`collect(range(3, 3))`, "[]"},
+		{`collect(range(9223372036854775806, 9223372036854775807, 5))`, "[9223372036854775806]"},
+		{`take(range(1e18), 3)`, "[0, 1, 2]"},
+		{`take([1, 2, 3], 5)`, "[1, 2, 3]"},
+		{`take("abc", 2)`, "[a, b]"},
+		{`collect({"x": 1})`, "[x]"},
+		{isPrime + `take(filter(range(1e9), isPrime), 10)`, "[2, 3, 5, 7, 11, 13, 17, 19, 23, 29]"},
+		{isPrime + `take(10, filter(isPrime, range(1e9)))`, "[2, 3, 5, 7, 11, 13, 17, 19, 23, 29]"},
+		{`take(2, map(fn(x) { x * 10 }, [1, 2, 3]))`, "[10, 20]"},
+		{`filter(fn(x) { x > 1 }, [1, 2, 3])`, "[2, 3]"},
+		{`map(first, [[1], [2]])`, "[1, 2]"},
+		{`take(2, 3)`, "ERROR: first argument to `take` must be iterable, got INTEGER"},
+		{`map(range(3), fn(x) { x * 10 })`, "iterator"},
+		{`collect(map(range(3), fn(x) { x * 10 }))`, "[0, 10, 20]"},
+		{`reduce(range(101), 0, fn(acc, x) { acc + x })`, "5050"},
+		{`find(range(10, 1e18), fn(x) { x / 7 * 7 == x })`, "14"},
+		{`sum(range(1, 5))`, "10"},
+		{`let calls = []; let it = map(range(1e18), fn(x) { let calls = push(calls, x); x }); take(it, 2); len(calls)`, "0"},
+		{`take(map(range(3), fn(x) { x / 0 }), 2)`, "ERROR: division by zero"},
+		{`range(1.5)`, "ERROR: arguments to `range` must be whole numbers, got 1.5"},
+		{`range(1, 2, 0)`, "ERROR: step of `range` must not be 0"},
+		{`range()`, "ERROR: wrong number of arguments. got=0, want=1 to 3"},
+		{`take(range(3), -1)`, "ERROR: second argument to `take` must be a non-negative INTEGER, got -1"},
+		{`take(5, range(3))`, "[0, 1, 2]"},
+		{`collect(1)`, "ERROR: argument to `collect` must be iterable, got INTEGER"},
+		{`reduce("ab", "", fn(acc, x) { acc + x })`, "ERROR: first argument to `reduce` must be ARRAY or ITERATOR, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package evaluator
+
+import (
+	"monkey_interpreter/object"
+	"strings"
+)
+
+// fileBuiltins read and write files through the interpreter's filesystem.
+// Reading needs READ access to the path and changing anything needs WRITE.
+var fileBuiltins = map[string]*object.Builtin{
+	"readFile": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			path, err := pathArg("readFile", object.READ, host, args)
+			if err != nil {
+				return err
+			}
+
+			data, readErr := host.Files().ReadFile(path)
+			if readErr != nil {
+				return newError("readFile: %s", readErr)
+			}
+			return &object.String{Value: string(data)}
+		},
+	},
+	"readLines": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			path, err := pathArg("readLines", object.READ, host, args)
+			if err != nil {
+				return err
+			}
+
+			data, readErr := host.Files().ReadFile(path)
+			if readErr != nil {
+				return newError("readLines: %s", readErr)
+			}
+
+			elements := []object.Object{}
+			text := strings.TrimSuffix(string(data), "\n")
+			if text == "" {
+				return object.NewArray(elements)
+			}
+			lines := strings.Split(text, "\n")
+			if err := host.Allocate(len(lines)); err != nil {
+				return err
+			}
+			for _, line := range lines {
+				elements = append(elements, &object.String{Value: strings.TrimSuffix(line, "\r")})
+			}
+			return object.NewArray(elements)
+		},
+	},
+	"writeFile": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			return writeFile("writeFile", host, args, false)
+		},
+	},
+	"appendFile": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			return writeFile("appendFile", host, args, true)
+		},
+	},
+	"listDir": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			path, err := pathArg("listDir", object.READ, host, args)
+			if err != nil {
+				return err
+			}
+
+			names, readErr := host.Files().ReadDir(path)
+			if readErr != nil {
+				return newError("listDir: %s", readErr)
+			}
+			if err := host.Allocate(len(names)); err != nil {
+				return err
+			}
+			elements := make([]object.Object, len(names))
+			for i, name := range names {
+				elements[i] = &object.String{Value: name}
+			}
+			return object.NewArray(elements)
+		},
+	},
+	"exists": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			path, err := pathArg("exists", object.READ, host, args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(host.Files().Exists(path))
+		},
+	},
+	"mkdir": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			path, err := pathArg("mkdir", object.WRITE, host, args)
+			if err != nil {
+				return err
+			}
+
+			if mkdirErr := host.Files().MkdirAll(path); mkdirErr != nil {
+				return newError("mkdir: %s", mkdirErr)
+			}
+			return NULL
+		},
+	},
+	"remove": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			path, err := pathArg("remove", object.WRITE, host, args)
+			if err != nil {
+				return err
+			}
+
+			if removeErr := host.Files().Remove(path); removeErr != nil {
+				return newError("remove: %s", removeErr)
+			}
+			return NULL
+		},
+	},
+}
+
+// pathArg checks that args start with a path the run may access with
+// capability, and returns the path
+func pathArg(name string, capability object.Capability, host object.Host, args []object.Object) (string, *object.Error) {
+	path, ok := args[0].(*object.String)
+	if !ok {
+		return "", newError("first argument to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+	if err := host.Check(capability, path.Value); err != nil {
+		return "", err
+	}
+	return path.Value, nil
+}
+
+func writeFile(name string, host object.Host, args []object.Object, append bool) object.Object {
+	path, err := pathArg(name, object.WRITE, host, args)
+	if err != nil {
+		return err
+	}
+	data, ok := args[1].(*object.String)
+	if !ok {
+		return newError("second argument to `%s` must be STRING, got %s", name, args[1].Type())
+	}
+
+	if writeErr := host.Files().WriteFile(path, []byte(data.Value), append); writeErr != nil {
+		return newError("%s: %s", name, writeErr)
+	}
+	return NULL
+}
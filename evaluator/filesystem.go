@@ -0,0 +1,232 @@
+package evaluator
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// OSFileSystem is the operating system's filesystem
+type OSFileSystem struct{}
+
+// ReadFile implements object.FileSystem
+func (OSFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// WriteFile implements object.FileSystem
+func (OSFileSystem) WriteFile(name string, data []byte, append bool) error {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if append {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(name, flag, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadDir implements object.FileSystem
+func (OSFileSystem) ReadDir(name string) ([]string, error) {
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names, nil
+}
+
+// Exists implements object.FileSystem
+func (OSFileSystem) Exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// MkdirAll implements object.FileSystem
+func (OSFileSystem) MkdirAll(name string) error {
+	return os.MkdirAll(name, 0755)
+}
+
+// Remove implements object.FileSystem
+func (OSFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+var (
+	errIsDir    = errors.New("is a directory")
+	errNotDir   = errors.New("not a directory")
+	errNotEmpty = errors.New("directory not empty")
+)
+
+// MemoryFileSystem is a filesystem held in memory, for tests and embedding.
+// Paths are cleaned and use forward slashes; "." and "/" always exist.
+type MemoryFileSystem struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	dirs  map[string]bool
+}
+
+// NewMemoryFileSystem returns a MemoryFileSystem holding files, which maps
+// paths to contents. The directories containing them are created too.
+func NewMemoryFileSystem(files map[string]string) *MemoryFileSystem {
+	m := &MemoryFileSystem{files: make(map[string][]byte), dirs: make(map[string]bool)}
+	for name, contents := range files {
+		name = cleanPath(name)
+		m.mkdirAll(path.Dir(name))
+		m.files[name] = []byte(contents)
+	}
+	return m
+}
+
+func cleanPath(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+func (m *MemoryFileSystem) isDir(name string) bool {
+	return name == "." || name == "/" || m.dirs[name]
+}
+
+// ReadFile implements object.FileSystem
+func (m *MemoryFileSystem) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clean := cleanPath(name)
+	data, ok := m.files[clean]
+	switch {
+	case ok:
+		return append([]byte{}, data...), nil
+	case m.isDir(clean):
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	default:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+}
+
+// WriteFile implements object.FileSystem
+func (m *MemoryFileSystem) WriteFile(name string, data []byte, append bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clean := cleanPath(name)
+	switch {
+	case m.isDir(clean):
+		return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+	case !m.isDir(path.Dir(clean)):
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if append {
+		m.files[clean] = appendBytes(m.files[clean], data)
+	} else {
+		m.files[clean] = appendBytes(nil, data)
+	}
+	return nil
+}
+
+func appendBytes(a, b []byte) []byte {
+	return append(append([]byte{}, a...), b...)
+}
+
+// ReadDir implements object.FileSystem
+func (m *MemoryFileSystem) ReadDir(name string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clean := cleanPath(name)
+	if !m.isDir(clean) {
+		if _, ok := m.files[clean]; ok {
+			return nil, &fs.PathError{Op: "readdirent", Path: name, Err: errNotDir}
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	names := m.children(clean)
+	sort.Strings(names)
+	return names, nil
+}
+
+// children returns the base names of the files and directories directly in dir
+func (m *MemoryFileSystem) children(dir string) []string {
+	names := []string{}
+	for file := range m.files {
+		if path.Dir(file) == dir {
+			names = append(names, path.Base(file))
+		}
+	}
+	for sub := range m.dirs {
+		if path.Dir(sub) == dir && sub != dir {
+			names = append(names, path.Base(sub))
+		}
+	}
+	return names
+}
+
+// Exists implements object.FileSystem
+func (m *MemoryFileSystem) Exists(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clean := cleanPath(name)
+	_, ok := m.files[clean]
+	return ok || m.isDir(clean)
+}
+
+// MkdirAll implements object.FileSystem
+func (m *MemoryFileSystem) MkdirAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if file := m.mkdirAll(cleanPath(name)); file != "" {
+		return &fs.PathError{Op: "mkdir", Path: file, Err: errNotDir}
+	}
+	return nil
+}
+
+// mkdirAll creates dir and its parents, returning the path of a file in the
+// way if there is one
+func (m *MemoryFileSystem) mkdirAll(dir string) string {
+	if m.isDir(dir) {
+		return ""
+	}
+	if _, ok := m.files[dir]; ok {
+		return dir
+	}
+	if file := m.mkdirAll(path.Dir(dir)); file != "" {
+		return file
+	}
+	m.dirs[dir] = true
+	return ""
+}
+
+// Remove implements object.FileSystem
+func (m *MemoryFileSystem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clean := cleanPath(name)
+	if _, ok := m.files[clean]; ok {
+		delete(m.files, clean)
+		return nil
+	}
+	switch {
+	case !m.dirs[clean]:
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	case len(m.children(clean)) > 0:
+		return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+	delete(m.dirs, clean)
+	return nil
+}
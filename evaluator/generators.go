@@ -0,0 +1,142 @@
+package evaluator
+
+import (
+	"monkey_interpreter/ast"
+	"monkey_interpreter/object"
+	"runtime"
+	"sync"
+)
+
+// Calling a generator function binds its arguments and returns a generator
+// without running the body. The body runs on a goroutine of its own, started
+// by the first call of Next, which hands control back and forth with whoever
+// calls Next so that only one of them evaluates at a time: Next waits while
+// the body runs up to its next yield, and the body waits at the yield until
+// Next is called again. A return ends the generator, the value returned being
+// dropped.
+//
+// The goroutine of a generator left waiting at a yield keeps everything its
+// body can reach alive, often the generator itself, so it is stopped when the
+// interpreter is closed rather than only when the generator is collected.
+// Until then a generator can be resumed by later runs, such as the following
+// lines of the REPL.
+
+// generator is the iterator a call to a generator function returns
+type generator struct {
+	in      *Interpreter
+	fn      *object.Function
+	env     *object.Environment
+	call    *ast.CallExpression
+	run     *generatorRun // nil until the body starts
+	running bool
+	done    bool
+}
+
+// generatorRun is what a generator shares with the goroutine running its
+// body. It does not refer back to the generator, so that one dropped before
+// its body finishes can be collected and the goroutine stopped.
+type generatorRun struct {
+	resume chan struct{}      // lets the body go on from a yield
+	yields chan object.Object // what the body yields, closed once it finishes
+	stop   chan struct{}      // closed by end to end the body where it is waiting
+	once   sync.Once
+}
+
+// end stops the body at the yield it is waiting at, if it has not finished
+func (run *generatorRun) end() {
+	run.once.Do(func() { close(run.stop) })
+}
+
+func (g *generator) Type() object.ObjectType { return object.ITERATOR_OBJ }
+func (g *generator) Inspect() string         { return "generator" }
+
+// Next runs the body until it yields or finishes, as a call of the generator
+// function would, with a frame of its own
+func (g *generator) Next() (object.Object, bool) {
+	if g.done {
+		return nil, false
+	}
+	if g.running {
+		return newError("generator is already running"), true
+	}
+	if err := g.in.enter(); err != nil {
+		return err, true
+	}
+
+	started := g.run != nil
+	if started {
+		select {
+		case <-g.run.stop:
+			g.done = true
+			return newError("generator was stopped when the interpreter was closed"), true
+		default:
+		}
+	} else {
+		g.run = &generatorRun{resume: make(chan struct{}), yields: make(chan object.Object), stop: make(chan struct{})}
+		runtime.SetFinalizer(g, func(g *generator) { g.run.end() })
+		if g.in.generators == nil {
+			g.in.generators = make(map[*generatorRun]bool)
+		}
+		g.in.generators[g.run] = true
+	}
+
+	g.running = true
+	outerFile := g.in.File
+	g.in.File, g.in.frames = g.fn.File, append(g.in.frames, &Frame{Function: g.fn, Call: g.call, Env: g.env, generator: g.run})
+	if started {
+		g.run.resume <- struct{}{}
+	} else {
+		go g.in.runGenerator(g.fn.Body, g.env, g.run)
+	}
+	value, ok := <-g.run.yields
+	g.in.File, g.in.frames = outerFile, g.in.frames[:len(g.in.frames)-1]
+	g.running = false
+
+	if !ok || isError(value) {
+		g.done = true
+	}
+	if !ok {
+		delete(g.in.generators, g.run)
+	}
+	return value, ok
+}
+
+// Close ends the bodies of the generators the interpreter started that have
+// not finished, releasing what they hold. Programs embedding an interpreter
+// for a session, like the REPL, close it once the session is over.
+func (in *Interpreter) Close() {
+	for run := range in.generators {
+		run.end()
+	}
+	in.generators = nil
+}
+
+// runGenerator evaluates the body of a generator on the goroutine started for it
+func (in *Interpreter) runGenerator(body *ast.BlockStatement, env *object.Environment, run *generatorRun) {
+	if result := in.Eval(body, env); isError(result) {
+		run.yields <- result
+	}
+	close(run.yields)
+}
+
+// yield hands value to the Next the generator whose body is running was
+// called from, then waits for the following one. It never returns when the
+// generator is dropped in the meantime.
+func (in *Interpreter) yield(value object.Object) object.Object {
+	var run *generatorRun
+	if len(in.frames) > 0 {
+		run = in.frames[len(in.frames)-1].generator
+	}
+	if run == nil {
+		return newError("yield outside a generator")
+	}
+
+	run.yields <- value
+	select {
+	case <-run.resume:
+		return nil
+	case <-run.stop:
+		runtime.Goexit()
+		return nil
+	}
+}
@@ -0,0 +1,180 @@
+package evaluator
+
+import "monkey_interpreter/object"
+
+// hashBuiltins inspect hashes and build modified copies of them. Results list
+// their pairs in insertion order.
+var hashBuiltins = map[string]*object.Builtin{
+	"keys": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			hash, err := hashArg("keys", args)
+			if err != nil {
+				return err
+			}
+			if err := host.Allocate(hash.Len()); err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, pair := range hash.Items() {
+				elements = append(elements, pair.Key)
+			}
+			return object.NewArray(elements)
+		},
+	},
+	"values": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			hash, err := hashArg("values", args)
+			if err != nil {
+				return err
+			}
+			if err := host.Allocate(hash.Len()); err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, pair := range hash.Items() {
+				elements = append(elements, pair.Value)
+			}
+			return object.NewArray(elements)
+		},
+	},
+	"items": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			hash, err := hashArg("items", args)
+			if err != nil {
+				return err
+			}
+			if err := host.Allocate(hash.Len()); err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, pair := range hash.Items() {
+				elements = append(elements, object.NewArray([]object.Object{pair.Key, pair.Value}))
+			}
+			return object.NewArray(elements)
+		},
+	},
+	"has": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			hash, err := hashArg("has", args)
+			if err != nil {
+				return err
+			}
+			return evalInExpression(args[1], hash)
+		},
+	},
+	"set": &object.Builtin{
+		MinArgs: 3, MaxArgs: 3,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			hash, err := hashArg("set", args)
+			if err != nil {
+				return err
+			}
+			key, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+
+			result := hash.Copy()
+			result.Set(key.HashKey(), object.HashPair{Key: args[1], Value: args[2]})
+			return result
+		},
+	},
+	"delete": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			hash, err := hashArg("delete", args)
+			if err != nil {
+				return err
+			}
+			key, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+
+			result := hash.Copy()
+			result.Delete(key.HashKey())
+			return result
+		},
+	},
+	"merge": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			hash, err := hashArg("merge", args)
+			if err != nil {
+				return err
+			}
+			other, ok := args[1].(*object.Hash)
+			if !ok {
+				return newError("arguments to `merge` must be HASH, got %s", args[1].Type())
+			}
+
+			result := hash.Copy()
+			for _, pair := range other.Items() {
+				result.Set(pair.Key.(object.Hashable).HashKey(), pair)
+			}
+			return result
+		},
+	},
+}
+
+// hashArg checks that args start with a hash and returns it
+func hashArg(name string, args []object.Object) (*object.Hash, *object.Error) {
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, newError("first argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+	return hash, nil
+}
+
+// evalInExpression reports whether left is a key of the hash, an element of
+// the array or a member of the module right
+func evalInExpression(left, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Hash:
+		key, ok := left.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", left.Type())
+		}
+		_, ok = right.Get(key.HashKey())
+		return nativeBoolToBooleanObject(ok)
+
+	case *object.Array:
+		for _, el := range right.Elements() {
+			if objectsEqual(left, el) {
+				return TRUE
+			}
+		}
+		return FALSE
+
+	case *object.Module:
+		name, ok := left.(*object.String)
+		if !ok {
+			return newError("module members are named by STRING, got %s", left.Type())
+		}
+		_, ok = right.Env.Get(name.Value)
+		return nativeBoolToBooleanObject(ok)
+
+	default:
+		return newError("unknown operator: %s in %s", left.Type(), right.Type())
+	}
+}
+
+// objectsEqual compares numbers, strings and booleans by value and other objects by identity, like ==
+func objectsEqual(a, b object.Object) bool {
+	switch a := a.(type) {
+	case *object.Integer, *object.Float:
+		return isNumber(b) && evalNumberInfixExpression("==", a, b) == TRUE
+	case *object.String:
+		b, ok := b.(*object.String)
+		return ok && a.Value == b.Value
+	default:
+		return a == b
+	}
+}
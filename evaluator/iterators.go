@@ -0,0 +1,247 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/object"
+)
+
+// Arrays, strings and hashes can be iterated over as well as iterators, giving
+// their elements, characters and keys in turn. The builtins given an iterator
+// return one too, doing no work until its elements are asked for, so
+// take(10, filter(isPrime, range(1e9))) only produces as many numbers as it
+// takes to find ten primes. take, map and filter take their arguments in
+// either order, so that is also take(filter(range(1e9), isPrime), 10).
+var iteratorBuiltins = map[string]*object.Builtin{
+	"range": &object.Builtin{
+		MinArgs: 1, MaxArgs: 3,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				n, ok := wholeNumber(arg)
+				if !ok {
+					return newError("arguments to `range` must be whole numbers, got %s", arg.Inspect())
+				}
+				bounds[i] = n
+			}
+
+			it := &rangeIterator{host: host, end: bounds[0], step: 1}
+			if len(bounds) > 1 {
+				it.next, it.end = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				it.step = bounds[2]
+			}
+			if it.step == 0 {
+				return newError("step of `range` must not be 0")
+			}
+			return it
+		},
+	},
+	"take": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			if _, ok := args[0].(*object.Integer); ok {
+				args = []object.Object{args[1], args[0]}
+			}
+			it, ok := iterate(args[0])
+			if !ok {
+				return newError("first argument to `take` must be iterable, got %s", args[0].Type())
+			}
+			n, ok := smallInt(args[1])
+			if !ok || n < 0 {
+				return newError("second argument to `take` must be a non-negative INTEGER, got %s", args[1].Inspect())
+			}
+			return collect(host, it, n)
+		},
+	},
+	"collect": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			it, ok := iterate(args[0])
+			if !ok {
+				return newError("argument to `collect` must be iterable, got %s", args[0].Type())
+			}
+			return collect(host, it, -1)
+		},
+	},
+}
+
+// iterate returns an iterator over the elements of obj, or false when obj
+// cannot be iterated over
+func iterate(obj object.Object) (object.Iterator, bool) {
+	switch obj := obj.(type) {
+	case object.Iterator:
+		return obj, true
+	case *object.Array:
+		return &sliceIterator{elements: obj.Elements()}, true
+	case *object.String:
+		chars := []object.Object{}
+		for _, r := range obj.Value {
+			chars = append(chars, &object.String{Value: string(r)})
+		}
+		return &sliceIterator{elements: chars}, true
+	case *object.Hash:
+		keys := []object.Object{}
+		for _, pair := range obj.Items() {
+			keys = append(keys, pair.Key)
+		}
+		return &sliceIterator{elements: keys}, true
+	}
+	return nil, false
+}
+
+// collect returns an array of the elements of it, stopping after limit of
+// them unless limit is negative, or the error it ends with. The array is
+// checked against the run's limits as it grows, so collecting an endless
+// iterator stops once the elements no longer fit.
+func collect(host object.Host, it object.Iterator, limit int64) object.Object {
+	elements := []object.Object{}
+	for limit < 0 || int64(len(elements)) < limit {
+		el, ok := it.Next()
+		if !ok {
+			break
+		}
+		if isError(el) {
+			return el
+		}
+		if err := host.Allocate(len(elements) + 1); err != nil {
+			return err
+		}
+		elements = append(elements, el)
+	}
+	return object.NewArray(elements)
+}
+
+// evalForExpression runs the body of node once for each element of its
+// iterable, destructuring the element into the environment the loop is in
+// first, like a let
+func (in *Interpreter) evalForExpression(node *ast.ForExpression, env *object.Environment) object.Object {
+	iterable := in.Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+	it, ok := iterate(iterable)
+	if !ok {
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+
+	for {
+		el, ok := it.Next()
+		if !ok {
+			return NULL
+		}
+		if isError(el) {
+			return el
+		}
+		if err := in.destructure(node.Pattern, el, env); err != nil {
+			return err
+		}
+
+		result := in.Eval(node.Body, env)
+		if result != nil {
+			rt := result.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return result
+			}
+		}
+	}
+}
+
+// sliceIterator goes through elements in order
+type sliceIterator struct {
+	elements []object.Object
+}
+
+func (it *sliceIterator) Type() object.ObjectType { return object.ITERATOR_OBJ }
+func (it *sliceIterator) Inspect() string         { return "iterator" }
+
+func (it *sliceIterator) Next() (object.Object, bool) {
+	if len(it.elements) == 0 {
+		return nil, false
+	}
+	el := it.elements[0]
+	it.elements = it.elements[1:]
+	return el, true
+}
+
+// rangeIterator counts from next by step, stopping short of end. Each number
+// is a step of the run, so counting through a range without doing anything
+// else with it is still bounded by the limits.
+type rangeIterator struct {
+	host            object.Host
+	next, end, step int64
+}
+
+func (it *rangeIterator) Type() object.ObjectType { return object.ITERATOR_OBJ }
+
+// Inspect shows the numbers left to count
+func (it *rangeIterator) Inspect() string {
+	if it.step == 1 {
+		return fmt.Sprintf("range(%d, %d)", it.next, it.end)
+	}
+	return fmt.Sprintf("range(%d, %d, %d)", it.next, it.end, it.step)
+}
+
+func (it *rangeIterator) Next() (object.Object, bool) {
+	if it.step > 0 && it.next >= it.end || it.step < 0 && it.next <= it.end {
+		return nil, false
+	}
+	if err := it.host.Step(); err != nil {
+		return err, true
+	}
+
+	n := it.next
+	if it.step > 0 && n > math.MaxInt64-it.step || it.step < 0 && n < math.MinInt64-it.step {
+		// the next number would overflow, so it is past end
+		it.next = it.end
+	} else {
+		it.next += it.step
+	}
+	return &object.Integer{Value: n}, true
+}
+
+// mappedIterator gives the result of calling fn with each element of source
+type mappedIterator struct {
+	host   object.Host
+	source object.Iterator
+	fn     object.Object
+}
+
+func (it *mappedIterator) Type() object.ObjectType { return object.ITERATOR_OBJ }
+func (it *mappedIterator) Inspect() string         { return "iterator" }
+
+func (it *mappedIterator) Next() (object.Object, bool) {
+	el, ok := it.source.Next()
+	if !ok || isError(el) {
+		return el, ok
+	}
+	return it.host.Call(it.fn, el), true
+}
+
+// filteredIterator gives the elements of source for which fn returns true
+type filteredIterator struct {
+	host   object.Host
+	source object.Iterator
+	fn     object.Object
+}
+
+func (it *filteredIterator) Type() object.ObjectType { return object.ITERATOR_OBJ }
+func (it *filteredIterator) Inspect() string         { return "iterator" }
+
+func (it *filteredIterator) Next() (object.Object, bool) {
+	for {
+		el, ok := it.source.Next()
+		if !ok || isError(el) {
+			return el, ok
+		}
+		keep := it.host.Call(it.fn, el)
+		if isError(keep) {
+			return keep, true
+		}
+		if isTruthy(keep) {
+			return el, true
+		}
+	}
+}
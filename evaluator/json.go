@@ -0,0 +1,196 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"monkey_interpreter/object"
+	"strconv"
+	"strings"
+)
+
+var jsonMembers = map[string]object.Object{
+	"parse": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("parse", args)
+			if err != nil {
+				return err
+			}
+
+			dec := json.NewDecoder(strings.NewReader(strs[0]))
+			dec.UseNumber()
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return err
+			}
+			if _, extra := dec.Token(); extra != io.EOF {
+				return newError("invalid JSON: unexpected data after top-level value")
+			}
+			return value
+		},
+	},
+	"stringify": &object.Builtin{
+		MinArgs: 1, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			var out bytes.Buffer
+			if err := encodeJSON(&out, args[0]); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				return &object.String{Value: out.String()}
+			}
+
+			var indent string
+			switch arg := args[1].(type) {
+			case *object.String:
+				indent = arg.Value
+			case *object.Integer:
+				n, ok := smallInt(arg)
+				if !ok || n < 0 || n > 10 {
+					return newError("indent passed to `stringify` must be between 0 and 10, got %s", arg.Inspect())
+				}
+				indent = strings.Repeat(" ", int(n))
+			default:
+				return newError("indent passed to `stringify` must be STRING or INTEGER, got %s", args[1].Type())
+			}
+
+			var indented bytes.Buffer
+			json.Indent(&indented, out.Bytes(), "", indent)
+			return &object.String{Value: indented.String()}
+		},
+	},
+}
+
+// decodeJSON reads the next JSON value from dec. Objects become hashes with
+// their keys in document order, and numbers become INTEGER when they are whole
+// and FLOAT otherwise.
+func decodeJSON(dec *json.Decoder) (object.Object, *object.Error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, invalidJSON(err)
+	}
+
+	switch tok := tok.(type) {
+	case nil:
+		return NULL, nil
+	case bool:
+		return nativeBoolToBooleanObject(tok), nil
+	case string:
+		return &object.String{Value: tok}, nil
+	case json.Number:
+		return decodeJSONNumber(tok)
+	case json.Delim:
+		if tok == '[' {
+			elements := []object.Object{}
+			for dec.More() {
+				element, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, element)
+			}
+			dec.Token()
+			return object.NewArray(elements), nil
+		}
+
+		hash := object.NewHash()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, invalidJSON(err)
+			}
+			key := &object.String{Value: keyTok.(string)}
+			value, decodeErr := decodeJSON(dec)
+			if decodeErr != nil {
+				return nil, decodeErr
+			}
+			hash.Set(key.HashKey(), object.HashPair{Key: key, Value: value})
+		}
+		dec.Token()
+		return hash, nil
+	}
+	return nil, newError("invalid JSON: unexpected %v", tok)
+}
+
+func decodeJSONNumber(num json.Number) (object.Object, *object.Error) {
+	if !strings.ContainsAny(string(num), ".eE") {
+		if n, err := strconv.ParseInt(string(num), 10, 64); err == nil {
+			return &object.Integer{Value: n}, nil
+		}
+		if n, ok := new(big.Int).SetString(string(num), 10); ok {
+			return object.NewBigInteger(n), nil
+		}
+	}
+
+	f, err := strconv.ParseFloat(string(num), 64)
+	if err != nil {
+		return nil, newError("invalid JSON: number %s is out of range", num)
+	}
+	return &object.Float{Value: f}, nil
+}
+
+func invalidJSON(err error) *object.Error {
+	if err == io.EOF {
+		return newError("invalid JSON: unexpected end of JSON input")
+	}
+	return newError("invalid JSON: %s", err)
+}
+
+// encodeJSON writes obj to out as compact JSON. Hashes are written in their
+// insertion order, so the output is deterministic.
+func encodeJSON(out *bytes.Buffer, obj object.Object) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Null:
+		out.WriteString("null")
+	case *object.Boolean, *object.Integer:
+		out.WriteString(obj.Inspect())
+	case *object.Float:
+		if math.IsInf(obj.Value, 0) || math.IsNaN(obj.Value) {
+			return newError("cannot encode %s as JSON", obj.Inspect())
+		}
+		out.WriteString(obj.Inspect())
+	case *object.String:
+		encodeJSONString(out, obj.Value)
+	case *object.Array:
+		out.WriteByte('[')
+		for i, element := range obj.Elements() {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, element); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case *object.Hash:
+		out.WriteByte('{')
+		for i, pair := range obj.Items() {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return newError("cannot encode %s hash key as JSON", pair.Key.Type())
+			}
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			encodeJSONString(out, key.Value)
+			out.WriteByte(':')
+			if err := encodeJSON(out, pair.Value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return newError("cannot encode %s as JSON", obj.Type())
+	}
+	return nil
+}
+
+func encodeJSONString(out *bytes.Buffer, s string) {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	out.Truncate(out.Len() - 1) // Encode adds a newline
+}
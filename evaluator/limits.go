@@ -0,0 +1,153 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/object"
+	"time"
+)
+
+// Codes of the errors returned when a run is aborted
+const (
+	StepLimitExceeded    = "STEP_LIMIT_EXCEEDED"
+	DepthLimitExceeded   = "DEPTH_LIMIT_EXCEEDED"
+	ElementLimitExceeded = "ELEMENT_LIMIT_EXCEEDED"
+	Timeout              = "TIMEOUT"
+	Canceled             = "CANCELED"
+)
+
+// DefaultMaxDepth is the MaxDepth of interpreters made by New. Deeper
+// recursion would overflow the Go stack and crash the process instead of
+// returning an error.
+const DefaultMaxDepth = 10000
+
+// Limits bounds the resources a run may use. Zero fields are unlimited.
+type Limits struct {
+	// MaxSteps is the number of nodes that may be evaluated
+	MaxSteps int
+	// MaxDepth is the number of function calls that may be in progress at once
+	MaxDepth int
+	// MaxElements is the number of array elements, hash pairs, string bytes and
+	// big integer bytes that may be allocated
+	MaxElements int
+	// Timeout is the wall-clock time a run may take
+	Timeout time.Duration
+}
+
+// IsAborted reports whether obj is an error from exceeding a limit or being
+// canceled, as opposed to an error raised by the program itself
+func IsAborted(obj object.Object) bool {
+	err, ok := obj.(*object.Error)
+	if !ok {
+		return false
+	}
+	switch err.Code {
+	case StepLimitExceeded, DepthLimitExceeded, ElementLimitExceeded, Timeout, Canceled:
+		return true
+	}
+	return false
+}
+
+// EvalContext evaluates node in env until it finishes, ctx is done or one of
+// the interpreter's Limits is exceeded. The step and element counts start from
+// zero.
+func (in *Interpreter) EvalContext(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
+	if in.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, in.Limits.Timeout)
+		defer cancel()
+	}
+
+	in.ctx, in.steps, in.elements, in.aborted = ctx, 0, 0, nil
+	defer func() { in.ctx = nil }()
+
+	return in.Eval(node, env)
+}
+
+// step counts the evaluation of a node, returning an error once the run must stop
+func (in *Interpreter) step() *object.Error {
+	if in.aborted != nil {
+		return in.aborted
+	}
+
+	in.steps++
+	switch {
+	case in.Limits.MaxSteps > 0 && in.steps > in.Limits.MaxSteps:
+		in.aborted = &object.Error{Code: StepLimitExceeded, Message: fmt.Sprintf("step limit of %d exceeded", in.Limits.MaxSteps)}
+	case in.ctx != nil:
+		select {
+		case <-in.ctx.Done():
+			if in.ctx.Err() == context.DeadlineExceeded {
+				in.aborted = &object.Error{Code: Timeout, Message: "evaluation timed out"}
+			} else {
+				in.aborted = &object.Error{Code: Canceled, Message: "evaluation canceled"}
+			}
+		default:
+		}
+	}
+	return in.aborted
+}
+
+// Step implements object.Host
+func (in *Interpreter) Step() *object.Error {
+	return in.step()
+}
+
+// enter checks that another function call fits within MaxDepth
+func (in *Interpreter) enter() *object.Error {
+	if in.Limits.MaxDepth > 0 && len(in.frames) >= in.Limits.MaxDepth {
+		in.aborted = &object.Error{Code: DepthLimitExceeded, Message: fmt.Sprintf("call depth limit of %d exceeded", in.Limits.MaxDepth)}
+	}
+	return in.aborted
+}
+
+// allocate counts the elements of a newly created obj against MaxElements
+func (in *Interpreter) allocate(obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Array:
+		in.elements += obj.Len()
+	case *object.Hash:
+		in.elements += obj.Len()
+	case *object.String:
+		in.elements += len(obj.Value)
+	case *object.Integer:
+		if obj.Big == nil {
+			return obj
+		}
+		in.elements += (obj.Big.BitLen() + 7) / 8
+	default:
+		return obj
+	}
+
+	if in.Limits.MaxElements > 0 && in.elements > in.Limits.MaxElements {
+		return in.exceedElements()
+	}
+	return obj
+}
+
+// isArgument reports whether obj is one of args, such as a number math.max
+// returns as it is, which was counted when it was made
+func isArgument(obj object.Object, args []object.Object) bool {
+	for _, arg := range args {
+		if obj == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// Allocate implements object.Host, checking that n more elements fit within
+// MaxElements without counting them
+func (in *Interpreter) Allocate(n int) *object.Error {
+	if in.aborted == nil && in.Limits.MaxElements > 0 && n > in.Limits.MaxElements-in.elements {
+		in.exceedElements()
+	}
+	return in.aborted
+}
+
+// exceedElements aborts the run for going over MaxElements
+func (in *Interpreter) exceedElements() *object.Error {
+	in.aborted = &object.Error{Code: ElementLimitExceeded, Message: fmt.Sprintf("element limit of %d exceeded", in.Limits.MaxElements)}
+	return in.aborted
+}
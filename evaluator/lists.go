@@ -0,0 +1,245 @@
+package evaluator
+
+import (
+	"monkey_interpreter/object"
+	"sort"
+)
+
+// listBuiltins take Monkey functions and call them back through the Host. Given
+// an iterator rather than an array, map and filter return an iterator calling
+// the function as its elements are asked for. zip, which takes no function,
+// pairs up the elements of two arrays or iterators until the shorter ends.
+var listBuiltins = map[string]*object.Builtin{
+	"map": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			args = functionFirst(args)
+			it, err := sequenceArg("map", args)
+			if err != nil {
+				return err
+			}
+
+			mapped := &mappedIterator{host: host, source: it, fn: args[1]}
+			if _, lazy := args[0].(object.Iterator); lazy {
+				return mapped
+			}
+			return collect(host, mapped, -1)
+		},
+	},
+	"filter": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			args = functionFirst(args)
+			it, err := sequenceArg("filter", args)
+			if err != nil {
+				return err
+			}
+
+			filtered := &filteredIterator{host: host, source: it, fn: args[1]}
+			if _, lazy := args[0].(object.Iterator); lazy {
+				return filtered
+			}
+			return collect(host, filtered, -1)
+		},
+	},
+	"reduce": &object.Builtin{
+		MinArgs: 3, MaxArgs: 3, Reuses: true,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			it, err := sequenceArg("reduce", args)
+			if err != nil {
+				return err
+			}
+
+			acc := args[1]
+			for {
+				el, ok := it.Next()
+				if !ok {
+					return acc
+				}
+				if isError(el) {
+					return el
+				}
+				acc = host.Call(args[2], acc, el)
+				if isError(acc) {
+					return acc
+				}
+			}
+		},
+	},
+	"find": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2, Reuses: true,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			it, err := sequenceArg("find", args)
+			if err != nil {
+				return err
+			}
+
+			for {
+				el, ok := it.Next()
+				if !ok {
+					return NULL
+				}
+				if isError(el) {
+					return el
+				}
+				found := host.Call(args[1], el)
+				if isError(found) {
+					return found
+				}
+				if isTruthy(found) {
+					return el
+				}
+			}
+		},
+	},
+	"flatMap": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			arr, err := arrayArg("flatMap", args)
+			if err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, el := range arr.Elements() {
+				result := host.Call(args[1], el)
+				if isError(result) {
+					return result
+				}
+				added := []object.Object{result}
+				if inner, ok := result.(*object.Array); ok {
+					added = inner.Elements()
+				}
+				if err := host.Allocate(len(elements) + len(added)); err != nil {
+					return err
+				}
+				elements = append(elements, added...)
+			}
+			return object.NewArray(elements)
+		},
+	},
+	"zip": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			a, err := sequenceArg("zip", args)
+			if err != nil {
+				return err
+			}
+			b, err := sequenceArg("zip", args[1:])
+			if err != nil {
+				return newError("second argument to `zip` must be ARRAY or ITERATOR, got %s", args[1].Type())
+			}
+
+			pairs := []object.Object{}
+			for {
+				x, ok := a.Next()
+				if !ok {
+					break
+				}
+				if isError(x) {
+					return x
+				}
+				y, ok := b.Next()
+				if !ok {
+					break
+				}
+				if isError(y) {
+					return y
+				}
+				if err := host.Allocate(len(pairs) + 1); err != nil {
+					return err
+				}
+				pairs = append(pairs, object.NewArray([]object.Object{x, y}))
+			}
+			return object.NewArray(pairs)
+		},
+	},
+	"sort": &object.Builtin{
+		MinArgs: 1, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("first argument to `sort` must be ARRAY, got %s", args[0].Type())
+			}
+			if err := host.Allocate(arr.Len()); err != nil {
+				return err
+			}
+
+			elements := arr.Elements()
+
+			var failed object.Object
+			less := func(a, b object.Object) bool {
+				if failed != nil {
+					return false
+				}
+				if len(args) == 1 {
+					result, err := naturalLess(a, b)
+					failed = err
+					return result
+				}
+
+				result := host.Call(args[1], a, b)
+				boolean, ok := result.(*object.Boolean)
+				if !ok {
+					failed = result
+					if !isError(result) {
+						failed = newError("comparator passed to `sort` must return BOOLEAN, got %s", result.Type())
+					}
+					return false
+				}
+				return boolean.Value
+			}
+
+			sort.SliceStable(elements, func(i, j int) bool { return less(elements[i], elements[j]) })
+			if failed != nil {
+				return failed
+			}
+			return object.NewArray(elements)
+		},
+	},
+}
+
+// arrayArg checks that args start with an array and returns it
+func arrayArg(name string, args []object.Object) (*object.Array, *object.Error) {
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, newError("first argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+	return arr, nil
+}
+
+// sequenceArg checks that args start with an array or an iterator and returns
+// an iterator over its elements
+func sequenceArg(name string, args []object.Object) (object.Iterator, *object.Error) {
+	switch arg := args[0].(type) {
+	case object.Iterator:
+		return arg, nil
+	case *object.Array:
+		return &sliceIterator{elements: arg.Elements()}, nil
+	}
+	return nil, newError("first argument to `%s` must be ARRAY or ITERATOR, got %s", name, args[0].Type())
+}
+
+// functionFirst returns the arguments of map or filter with the sequence
+// first when they were given with the function first, as in
+// filter(isPrime, range(1e9))
+func functionFirst(args []object.Object) []object.Object {
+	switch args[0].(type) {
+	case *object.Function, *object.Builtin:
+		return []object.Object{args[1], args[0]}
+	}
+	return args
+}
+
+// naturalLess orders numbers numerically and strings lexically
+func naturalLess(a, b object.Object) (bool, object.Object) {
+	if isNumber(a) && isNumber(b) {
+		return evalNumberInfixExpression("<", a, b) == TRUE, nil
+	}
+	if a, ok := a.(*object.String); ok {
+		if b, ok := b.(*object.String); ok {
+			return a.Value < b.Value, nil
+		}
+	}
+	return false, newError("cannot sort %s and %s without a comparator", a.Type(), b.Type())
+}
@@ -0,0 +1,169 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/object"
+)
+
+// evalMatchExpression evaluates the result of the first arm whose pattern
+// matches the subject and whose guard holds. Each arm is tried in an
+// environment of its own, enclosed by the one the match is in, where the names
+// its pattern binds are set before its guard runs. It is dropped once the arm
+// is done with, so those names are never seen outside the arm.
+func (in *Interpreter) evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := in.Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := object.NewLocalEnvironment(env, arm.Locals)
+		bindings := []patternBinding{}
+		mismatch, err := in.matchPattern(arm.Pattern, subject, armEnv, &bindings)
+		if err != nil {
+			return err
+		}
+		if mismatch != "" {
+			continue
+		}
+		for _, b := range bindings {
+			bind(armEnv, b.name, b.value)
+		}
+
+		if arm.Guard != nil {
+			guard := in.Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return in.Eval(arm.Result, armEnv)
+	}
+
+	return newError("no pattern matches %s", subject.Inspect())
+}
+
+// destructure binds the names in pattern to the parts of value, as let and
+// parameters do, failing when value does not have the shape of pattern
+func (in *Interpreter) destructure(pattern ast.Pattern, value object.Object, env *object.Environment) object.Object {
+	bindings := []patternBinding{}
+	mismatch, err := in.matchPattern(pattern, value, env, &bindings)
+	if err != nil {
+		return err
+	}
+	if mismatch != "" {
+		return newError("cannot destructure %s: %s", value.Inspect(), mismatch)
+	}
+	for _, b := range bindings {
+		bind(env, b.name, b.value)
+	}
+	return nil
+}
+
+// patternBinding is a value a pattern binds, kept until the whole pattern matches
+type patternBinding struct {
+	name  *ast.Identifier
+	value object.Object
+}
+
+// matchPattern matches value against pattern, adding what it binds to
+// bindings. It returns why value does not match, or "" when it does, and any
+// error evaluating the defaults and keys of the pattern.
+func (in *Interpreter) matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment, bindings *[]patternBinding) (string, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return "", nil
+
+	case *ast.BindingPattern:
+		*bindings = append(*bindings, patternBinding{name: pattern.Name, value: value})
+		return "", nil
+
+	case *ast.LiteralPattern:
+		literal := in.Eval(pattern.Value, env)
+		if !objectsEqual(value, literal) {
+			return fmt.Sprintf("%s is not %s", value.Inspect(), literal.Inspect()), nil
+		}
+		return "", nil
+
+	case *ast.ArrayPattern:
+		arr, ok := value.(*object.Array)
+		if !ok {
+			return fmt.Sprintf("%s is not an array", value.Inspect()), nil
+		}
+		n, required := len(pattern.Elements), 0
+		for i, el := range pattern.Elements {
+			if _, ok := el.(*ast.DefaultPattern); !ok {
+				required = i + 1
+			}
+		}
+		switch {
+		case pattern.Rest != nil && arr.Len() < required:
+			return fmt.Sprintf("%s has %d elements, want at least %d", arr.Inspect(), arr.Len(), required), nil
+		case pattern.Rest == nil && required == n && arr.Len() != n:
+			return fmt.Sprintf("%s has %d elements, want %d", arr.Inspect(), arr.Len(), n), nil
+		case pattern.Rest == nil && (arr.Len() < required || arr.Len() > n):
+			return fmt.Sprintf("%s has %d elements, want %d to %d", arr.Inspect(), arr.Len(), required, n), nil
+		}
+		for i, el := range pattern.Elements {
+			var element object.Object
+			if i < arr.Len() {
+				element = arr.Get(i)
+			}
+			if mismatch, err := in.matchElement(el, element, env, bindings); mismatch != "" || err != nil {
+				return mismatch, err
+			}
+		}
+		if pattern.Rest == nil {
+			return "", nil
+		}
+		rest := arr.Slice(arr.Len(), arr.Len())
+		if n < arr.Len() {
+			rest = arr.Slice(n, arr.Len())
+		}
+		return in.matchPattern(pattern.Rest, rest, env, bindings)
+
+	case *ast.HashPattern:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return fmt.Sprintf("%s is not a hash", value.Inspect()), nil
+		}
+		for i, key := range pattern.Keys {
+			k := in.Eval(key, env)
+			hashKey, ok := k.(object.Hashable)
+			if !ok {
+				return "", newError("unusable as hash key: %s", k.Type())
+			}
+			var element object.Object
+			if pair, ok := hash.Get(hashKey.HashKey()); ok {
+				element = pair.Value
+			} else if _, ok := pattern.Values[i].(*ast.DefaultPattern); !ok {
+				return fmt.Sprintf("%s has no key %s", hash.Inspect(), k.Inspect()), nil
+			}
+			if mismatch, err := in.matchElement(pattern.Values[i], element, env, bindings); mismatch != "" || err != nil {
+				return mismatch, err
+			}
+		}
+		return "", nil
+	}
+
+	return fmt.Sprintf("%s does not match %s", value.Inspect(), pattern.String()), nil
+}
+
+// matchElement matches an element of an array or hash pattern against value,
+// which is nil when the element is missing and pattern must have a default
+func (in *Interpreter) matchElement(pattern ast.Pattern, value object.Object, env *object.Environment, bindings *[]patternBinding) (string, *object.Error) {
+	dp, ok := pattern.(*ast.DefaultPattern)
+	if !ok {
+		return in.matchPattern(pattern, value, env, bindings)
+	}
+	if value == nil {
+		value = in.Eval(dp.Default, env)
+		if err, ok := value.(*object.Error); ok {
+			return "", err
+		}
+	}
+	return in.matchPattern(dp.Pattern, value, env, bindings)
+}
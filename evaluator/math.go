@@ -0,0 +1,160 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"monkey_interpreter/object"
+)
+
+var mathMembers = map[string]object.Object{
+	"pi": &object.Float{Value: math.Pi},
+	"e":  &object.Float{Value: math.E},
+
+	"abs": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			nums, err := numberArgs("abs", args)
+			if err != nil {
+				return err
+			}
+			if nums[0].Type() == object.FLOAT_OBJ {
+				return &object.Float{Value: math.Abs(toFloat(nums[0]))}
+			}
+			if evalNumberInfixExpression("<", nums[0], &object.Integer{Value: 0}) == TRUE {
+				return evalMinusPrefixOperatorExpression(nums[0])
+			}
+			return nums[0]
+		},
+	},
+	"min": &object.Builtin{
+		MinArgs: 1, MaxArgs: -1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			return extremum("min", "<", args)
+		},
+	},
+	"max": &object.Builtin{
+		MinArgs: 1, MaxArgs: -1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			return extremum("max", ">", args)
+		},
+	},
+	"pow": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			nums, err := numberArgs("pow", args)
+			if err != nil {
+				return err
+			}
+			base, ok := nums[0].(*object.Integer)
+			exp, isInt := nums[1].(*object.Integer)
+			if ok && isInt && exp.BigValue().Sign() >= 0 {
+				size, fits := powerSize(base, exp)
+				if !fits {
+					return newError("exponent too large: %s", exp.Inspect())
+				}
+				if err := host.Allocate(size); err != nil {
+					return err
+				}
+				return object.NewBigInteger(new(big.Int).Exp(base.BigValue(), exp.BigValue(), nil))
+			}
+			return &object.Float{Value: math.Pow(toFloat(nums[0]), toFloat(nums[1]))}
+		},
+	},
+	"floor": roundingBuiltin("floor", math.Floor),
+	"ceil":  roundingBuiltin("ceil", math.Ceil),
+	"round": roundingBuiltin("round", math.Round),
+	"sqrt":  floatBuiltin("sqrt", math.Sqrt),
+	"sin":   floatBuiltin("sin", math.Sin),
+	"cos":   floatBuiltin("cos", math.Cos),
+	"tan":   floatBuiltin("tan", math.Tan),
+	"atan":  floatBuiltin("atan", math.Atan),
+	"log":   floatBuiltin("log", math.Log),
+	"exp":   floatBuiltin("exp", math.Exp),
+}
+
+// maxPowerBits caps the size of an integer power whatever the limits are:
+// big.Int.Exp neither checks the deadline nor can be interrupted, so a larger
+// result could run past -timeout or exhaust memory before any limit sees it.
+const maxPowerBits = 1 << 24
+
+// powerSize returns an upper bound of the bytes base to the power of exp
+// takes, or false when that exceeds maxPowerBits. Powers of 0, 1 and -1 stay
+// that small whatever exp is.
+func powerSize(base, exp *object.Integer) (int, bool) {
+	n, ok := smallInt(exp)
+	if !ok {
+		return 0, false
+	}
+	bits := int64(base.BigValue().BitLen())
+	if bits <= 1 {
+		return 1, true
+	}
+	if n > maxPowerBits/bits {
+		return 0, false
+	}
+	return int(n*bits/8 + 1), true
+}
+
+// numberArgs checks that args are numbers
+func numberArgs(name string, args []object.Object) ([]object.Object, *object.Error) {
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return nil, newError("arguments to `%s` must be INTEGER or FLOAT, got %s", name, arg.Type())
+		}
+	}
+	return args, nil
+}
+
+// extremum returns the argument x for which x operator y holds against every other y
+func extremum(name, operator string, args []object.Object) object.Object {
+	nums, err := numberArgs(name, args)
+	if err != nil {
+		return err
+	}
+
+	result := nums[0]
+	for _, num := range nums[1:] {
+		if evalNumberInfixExpression(operator, num, result) == TRUE {
+			result = num
+		}
+	}
+	return result
+}
+
+// floatBuiltin wraps a float64 function of one number
+func floatBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			nums, err := numberArgs(name, args)
+			if err != nil {
+				return err
+			}
+			return &object.Float{Value: fn(toFloat(nums[0]))}
+		},
+	}
+}
+
+// roundingBuiltin wraps a float64 rounding function, returning an INTEGER.
+// Integers are already whole and are returned unchanged.
+func roundingBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			nums, err := numberArgs(name, args)
+			if err != nil {
+				return err
+			}
+			if nums[0].Type() == object.INTEGER_OBJ {
+				return nums[0]
+			}
+
+			rounded := fn(toFloat(nums[0]))
+			if math.IsInf(rounded, 0) || math.IsNaN(rounded) {
+				return newError("cannot %s %s to an INTEGER", name, nums[0].Inspect())
+			}
+			integer, _ := big.NewFloat(rounded).Int(nil)
+			return object.NewBigInteger(integer)
+		},
+	}
+}
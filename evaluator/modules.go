@@ -0,0 +1,141 @@
+package evaluator
+
+import (
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/resolver"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleExtension is added to imported paths that have no extension
+const ModuleExtension = ".mk"
+
+// builtinModules are modules provided by the interpreter. They are bound like
+// builtins and can also be imported by name.
+var builtinModules = map[string]*object.Module{
+	"json": newBuiltinModule("json", jsonMembers),
+	"math": newBuiltinModule("math", mathMembers),
+}
+
+// IsBuiltinModule reports whether name refers to a builtin module
+func IsBuiltinModule(name string) bool {
+	_, ok := builtinModules[name]
+	return ok
+}
+
+func newBuiltinModule(name string, members map[string]object.Object) *object.Module {
+	env := object.NewEnvironment()
+	for member, value := range members {
+		env.Set(member, value)
+	}
+	return &object.Module{Path: name, Env: env}
+}
+
+// Import implements object.Host. Builtin modules such as math are returned as is.
+// Other modules are evaluated once per interpreter in a fresh environment; later
+// imports of the same file return the cached module. Module files are read
+// through the interpreter's filesystem and need READ access like readFile.
+func (in *Interpreter) Import(path string) object.Object {
+	if module, ok := builtinModules[path]; ok {
+		return module
+	}
+
+	file, err := in.resolve(path)
+	if err != nil {
+		return err
+	}
+
+	if module, ok := in.modules[file]; ok {
+		return module
+	}
+	for i, loading := range in.loading {
+		if loading == file {
+			cycle := append(append([]string{}, in.loading[i:]...), file)
+			return newError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	source, readErr := in.Files().ReadFile(file)
+	if readErr != nil {
+		return newError("cannot import %q: %s", path, readErr)
+	}
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError("cannot import %q: %s", path, strings.Join(p.Errors(), "; "))
+	}
+	resolver.Resolve(program)
+
+	outerFile := in.File
+	in.File, in.loading = file, append(in.loading, file)
+	env := object.NewEnvironment()
+	evaluated := in.Eval(program, env)
+	in.File, in.loading = outerFile, in.loading[:len(in.loading)-1]
+
+	if isError(evaluated) {
+		return evaluated
+	}
+
+	module := &object.Module{Path: file, Env: env}
+	if in.modules == nil {
+		in.modules = make(map[string]*object.Module)
+	}
+	in.modules[file] = module
+	return module
+}
+
+// resolve finds the file path refers to. Paths starting with ./ or ../ are
+// relative to the importing file only; others are also looked up in SearchPath.
+// Candidates the run may not read are skipped without looking at them, and
+// the first of them is reported if no other is found.
+func (in *Interpreter) resolve(path string) (string, *object.Error) {
+	if filepath.Ext(path) == "" {
+		path += ModuleExtension
+	}
+
+	dirs := []string{filepath.Dir(in.File)}
+	switch {
+	case filepath.IsAbs(path):
+		dirs = []string{""}
+	case !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../"):
+		dirs = append(dirs, in.SearchPath...)
+	}
+
+	var denied *object.Error
+	for _, dir := range dirs {
+		candidate, err := filepath.Abs(filepath.Join(dir, path))
+		if err != nil {
+			continue
+		}
+		if err := in.Check(object.READ, candidate); err != nil {
+			if denied == nil {
+				denied = err
+			}
+			continue
+		}
+		if in.Files().Exists(candidate) {
+			return candidate, nil
+		}
+	}
+	if denied != nil {
+		return "", denied
+	}
+	return "", newError("module not found: %s", path)
+}
+
+func evalModuleIndexExpression(module, index object.Object) object.Object {
+	moduleObject := module.(*object.Module)
+
+	name, ok := index.(*object.String)
+	if !ok {
+		return newError("module members are named by STRING, got %s", index.Type())
+	}
+
+	value, ok := moduleObject.Env.Get(name.Value)
+	if !ok {
+		return newError("module %s has no member %s", moduleObject.Path, name.Value)
+	}
+	return value
+}
@@ -0,0 +1,156 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"monkey_interpreter/object"
+)
+
+// Integers are int64 until an operation overflows, when the result is
+// promoted to a big.Int. Mixing an integer with a float gives a float.
+
+func isNumber(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.INTEGER_OBJ || t == object.FLOAT_OBJ
+}
+
+// toFloat converts an Integer or Float to float64
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Float:
+		return obj.Value
+	case *object.Integer:
+		if obj.Big != nil {
+			f, _ := new(big.Float).SetInt(obj.Big).Float64()
+			return f
+		}
+		return float64(obj.Value)
+	}
+	return math.NaN()
+}
+
+// smallInt returns the value of an Integer that fits in an int64
+func smallInt(obj object.Object) (int64, bool) {
+	integer, ok := obj.(*object.Integer)
+	if !ok || integer.Big != nil {
+		return 0, false
+	}
+	return integer.Value, true
+}
+
+// wholeNumber returns the value of an Integer that fits in an int64, or of a
+// Float in that range with nothing after the point, such as 1e9
+func wholeNumber(obj object.Object) (int64, bool) {
+	if f, ok := obj.(*object.Float); ok {
+		if f.Value != math.Trunc(f.Value) || f.Value < math.MinInt64 || f.Value >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f.Value), true
+	}
+	return smallInt(obj)
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Big == nil && right.Value != math.MinInt64 {
+			return &object.Integer{Value: -right.Value}
+		}
+		return object.NewBigInteger(new(big.Int).Neg(right.BigValue()))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError("unknown operator: -%s", right.Type())
+	}
+}
+
+func evalNumberInfixExpression(operator string, left, right object.Object) object.Object {
+	if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
+		return evalIntegerInfixExpression(operator, left.(*object.Integer), right.(*object.Integer))
+	}
+
+	leftVal, rightVal := toFloat(left), toFloat(right)
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalIntegerInfixExpression(operator string, left, right *object.Integer) object.Object {
+	if operator == "/" && right.Big == nil && right.Value == 0 {
+		return newError("division by zero")
+	}
+
+	if left.Big == nil && right.Big == nil {
+		leftVal, rightVal := left.Value, right.Value
+
+		switch operator {
+		case "+":
+			if sum := leftVal + rightVal; (sum > leftVal) == (rightVal > 0) {
+				return &object.Integer{Value: sum}
+			}
+		case "-":
+			if diff := leftVal - rightVal; (diff < leftVal) == (rightVal > 0) {
+				return &object.Integer{Value: diff}
+			}
+		case "*":
+			product := leftVal * rightVal
+			if leftVal == 0 || (product/leftVal == rightVal && !(leftVal == -1 && rightVal == math.MinInt64)) {
+				return &object.Integer{Value: product}
+			}
+		case "/":
+			if !(leftVal == math.MinInt64 && rightVal == -1) {
+				return &object.Integer{Value: leftVal / rightVal}
+			}
+		case "<":
+			return nativeBoolToBooleanObject(leftVal < rightVal)
+		case ">":
+			return nativeBoolToBooleanObject(leftVal > rightVal)
+		case "==":
+			return nativeBoolToBooleanObject(leftVal == rightVal)
+		case "!=":
+			return nativeBoolToBooleanObject(leftVal != rightVal)
+		default:
+			return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		}
+	}
+
+	// the result overflows or an operand is already big
+	leftVal, rightVal := left.BigValue(), right.BigValue()
+	switch operator {
+	case "+":
+		return object.NewBigInteger(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return object.NewBigInteger(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return object.NewBigInteger(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		return object.NewBigInteger(new(big.Int).Quo(leftVal, rightVal))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
@@ -0,0 +1,132 @@
+package evaluator
+
+import (
+	"errors"
+	"monkey_interpreter/object"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PermissionDenied is the code of the error returned when a builtin needs a capability the run lacks
+const PermissionDenied = "PERMISSION_DENIED"
+
+// Policy decides which capabilities a run has. The zero Policy denies everything.
+type Policy struct {
+	// Read and Write list the files and directories that may be accessed; "*" allows any path
+	Read, Write []string
+	// Env lists the environment variables that may be read; "*" allows any variable
+	Env []string
+
+	Clock, Random, Output bool
+}
+
+// AllowAll is a Policy granting every capability
+var AllowAll = Policy{
+	Read:   []string{"*"},
+	Write:  []string{"*"},
+	Env:    []string{"*"},
+	Clock:  true,
+	Random: true,
+	Output: true,
+}
+
+// Allows reports whether the policy grants capability on target
+func (p Policy) Allows(capability object.Capability, target string) bool {
+	switch capability {
+	case object.READ:
+		return allowsPath(p.Read, target)
+	case object.WRITE:
+		return allowsPath(p.Write, target)
+	case object.ENV:
+		for _, name := range p.Env {
+			if name == "*" || name == target {
+				return true
+			}
+		}
+		return false
+	case object.CLOCK:
+		return p.Clock
+	case object.RANDOM:
+		return p.Random
+	case object.OUTPUT:
+		return p.Output
+	default:
+		return false
+	}
+}
+
+// allowsPath reports whether path is one of allowed or inside one of them.
+// Paths are compared once made absolute and with their symbolic links
+// resolved, so a link inside an allowed directory cannot lead out of it.
+func allowsPath(allowed []string, path string) bool {
+	target, err := realPath(path)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range allowed {
+		if entry == "*" {
+			return true
+		}
+		root, err := realPath(entry)
+		if err != nil {
+			continue
+		}
+		if target == root || strings.HasPrefix(target, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// realPath makes path absolute and resolves the symbolic links in it. The
+// parts of it that do not exist yet, such as a file about to be written, are
+// joined to the deepest directory that does once that is resolved. A link to
+// something that does not exist is followed too, since writing through it
+// would create its target.
+func realPath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		path = wd + string(filepath.Separator) + path
+	}
+
+	missing := []string{}
+	for dir, links := path, 0; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if target, err := os.Readlink(dir); err == nil {
+			if links++; links > 255 {
+				return "", errors.New("too many levels of symbolic links in " + path)
+			}
+			if !filepath.IsAbs(target) {
+				target = dir[:strings.LastIndexByte(dir, filepath.Separator)+1] + target
+			}
+			dir = target
+			continue
+		}
+		i := strings.LastIndexByte(dir, filepath.Separator)
+		if i < 0 || dir[:i] == "" || dir[:i] == filepath.VolumeName(dir) {
+			return filepath.Join(append([]string{dir}, missing...)...), nil
+		}
+		missing = append([]string{dir[i+1:]}, missing...)
+		dir = dir[:i]
+	}
+}
+
+// Check implements object.Host, consulting the interpreter's Policy
+func (in *Interpreter) Check(capability object.Capability, target string) *object.Error {
+	if in.Policy.Allows(capability, target) {
+		return nil
+	}
+
+	what := string(capability) + " access"
+	if target != "" {
+		what += " to " + target
+	}
+	return &object.Error{Code: PermissionDenied, Message: "permission denied: " + what + " (run with --allow-" + string(capability) + ")"}
+}
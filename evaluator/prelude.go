@@ -0,0 +1,105 @@
+package evaluator
+
+import (
+	"embed"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/resolver"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The prelude is a set of functions written in Monkey that every program can
+// call without importing them. It is parsed once per process and bound once
+// per Interpreter, the first time a program refers to one of its names. Its
+// files may only contain let statements binding function literals.
+
+//go:embed prelude/*.mk
+var preludeFiles embed.FS
+
+var (
+	preludeOnce  sync.Once
+	preludeLets  []*ast.LetStatement
+	preludeNames map[string]bool
+)
+
+func parsePrelude() {
+	preludeNames = make(map[string]bool)
+
+	entries, err := preludeFiles.ReadDir("prelude")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		source, err := preludeFiles.ReadFile("prelude/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+
+		p := parser.New(lexer.New(string(source)))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			panic("prelude/" + entry.Name() + ": " + strings.Join(p.Errors(), "; "))
+		}
+		resolver.Resolve(program)
+
+		for _, statement := range program.Statements {
+			let, ok := statement.(*ast.LetStatement)
+			if !ok {
+				panic("prelude/" + entry.Name() + ": only let statements are allowed, got " + statement.String())
+			}
+			if let.Name == nil {
+				panic("prelude/" + entry.Name() + ": only lets binding a name are allowed, got " + let.String())
+			}
+			if _, ok := let.Value.(*ast.FunctionLiteral); !ok {
+				panic("prelude/" + entry.Name() + ": " + let.Name.Value + " must be bound to a function literal")
+			}
+			preludeNames[let.Name.Value] = true
+			preludeLets = append(preludeLets, let)
+		}
+	}
+}
+
+// isPrelude reports whether name is defined by the prelude
+func isPrelude(name string) bool {
+	preludeOnce.Do(parsePrelude)
+	return preludeNames[name]
+}
+
+// PreludeNames returns the names the prelude defines in sorted order
+func PreludeNames() []string {
+	preludeOnce.Do(parsePrelude)
+	names := make([]string, 0, len(preludeNames))
+	for name := range preludeNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// preludeEnv returns the environment holding the prelude, binding it on first use
+func (in *Interpreter) preludeEnv() *object.Environment {
+	if in.prelude != nil {
+		return in.prelude
+	}
+	preludeOnce.Do(parsePrelude)
+
+	in.prelude = object.NewEnvironment()
+	for _, let := range preludeLets {
+		fn := let.Value.(*ast.FunctionLiteral)
+		in.prelude.Set(let.Name.Value, &object.Function{
+			Parameters: fn.Parameters,
+			Patterns:   fn.ParameterPatterns,
+			Defaults:   fn.ParameterDefaults,
+			Variadic:   fn.Variadic,
+			Generator:  fn.Generator,
+			Body:       fn.Body,
+			Env:        in.prelude,
+			Locals:     fn.Locals,
+		})
+	}
+	return in.prelude
+}
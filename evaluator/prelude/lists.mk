@@ -0,0 +1,41 @@
+// Functions over arrays and iterators, complementing the native map, filter,
+// reduce, find, flatMap, sort and zip. Each walks its argument with for or
+// reduce rather than by recursion, so it takes iterators as well as arrays and
+// works on arrays of any length.
+
+// any reports whether test returns true for some element of seq, stopping at
+// the first that it does
+let any = fn(seq, test) {
+    for (x in seq) {
+        if (test(x)) {
+            return true
+        }
+    };
+    false
+};
+
+// all reports whether test returns true for every element of seq, stopping at
+// the first that it does not
+let all = fn(seq, test) {
+    for (x in seq) {
+        if (!test(x)) {
+            return false
+        }
+    };
+    true
+};
+
+// reverse returns the elements of seq in reverse order
+let reverse = fn(seq) {
+    let arr = collect(seq);
+    let out = [];
+    for (i in range(len(arr) - 1, -1, -1)) {
+        let out = push(out, arr[i])
+    };
+    out
+};
+
+// concat returns the elements of a followed by those of b
+let concat = fn(a, b) {
+    reduce(b, collect(a), push)
+};
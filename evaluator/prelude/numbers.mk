@@ -0,0 +1,12 @@
+// Functions over numbers
+
+// sum adds up the elements of arr
+let sum = fn(arr) {
+    reduce(arr, 0, fn(acc, x) { acc + x })
+};
+
+// sortBy returns the elements of arr ordered by key, which must return integers.
+// Equal keys keep their order.
+let sortBy = fn(arr, key) {
+    sort(arr, fn(a, b) { key(a) < key(b) })
+};
@@ -0,0 +1,103 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"monkey_interpreter/object"
+	"strings"
+)
+
+// formatString renders args according to format, which supports the verbs
+//
+//	%d  INTEGER in decimal      %x  INTEGER in hexadecimal
+//	%f  FLOAT or INTEGER        %s  STRING
+//	%q  STRING quoted           %t  BOOLEAN
+//	%v  any value as Inspect    %%  a literal percent sign
+//
+// each optionally preceded by the flags -, 0 and +, a width and a .precision.
+func formatString(format string, args []object.Object) object.Object {
+	var out strings.Builder
+	next := 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		for i++; i < len(format) && strings.IndexByte("-+.0123456789", format[i]) >= 0; i++ {
+		}
+		if i == len(format) {
+			return newError("format %q ends in an incomplete verb", format)
+		}
+		spec, verb := format[start:i], format[i]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		if next == len(args) {
+			return newError("format %q is missing an argument for %s%c", format, spec, verb)
+		}
+		arg := args[next]
+		next++
+
+		var value interface{}
+		switch verb {
+		case 'd', 'x':
+			integer, ok := arg.(*object.Integer)
+			if !ok {
+				return newError("%%%c in format needs INTEGER, got %s", verb, arg.Type())
+			}
+			value = integer.BigValue()
+		case 'f':
+			if !isNumber(arg) {
+				return newError("%%f in format needs FLOAT or INTEGER, got %s", arg.Type())
+			}
+			value = toFloat(arg)
+		case 's', 'q':
+			str, ok := arg.(*object.String)
+			if !ok {
+				return newError("%%%c in format needs STRING, got %s", verb, arg.Type())
+			}
+			value = str.Value
+		case 't':
+			boolean, ok := arg.(*object.Boolean)
+			if !ok {
+				return newError("%%t in format needs BOOLEAN, got %s", arg.Type())
+			}
+			value = boolean.Value
+		case 'v':
+			value, verb = arg.Inspect(), 's'
+		default:
+			return newError("unknown verb %%%c in format", verb)
+		}
+		fmt.Fprintf(&out, spec+string(verb), value)
+	}
+
+	if next < len(args) {
+		return newError("format %q has %d arguments too many", format, len(args)-next)
+	}
+	return &object.String{Value: out.String()}
+}
+
+// write prints s to the host's output if the run may produce output
+func write(host object.Host, s string) object.Object {
+	if err := host.Check(object.OUTPUT, ""); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(host.Output(), s); err != nil {
+		return newError("cannot write output: %s", err)
+	}
+	return NULL
+}
+
+// inspectAll joins the Inspect of each of args with sep
+func inspectAll(args []object.Object, sep string) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg.Inspect()
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"monkey_interpreter/ast"
+	"monkey_interpreter/object"
+)
+
+// Arrays and strings are indexed from the front by non-negative integers and
+// from the back by negative ones, so -1 is the last element.
+
+// sequenceIndex converts index to an offset into a sequence of length,
+// reporting whether it is in range
+func sequenceIndex(index object.Object, length int) (int, bool) {
+	idx, ok := smallInt(index)
+	if !ok {
+		return 0, false
+	}
+	if idx < 0 {
+		idx += int64(length)
+	}
+	if idx < 0 || idx >= int64(length) {
+		return 0, false
+	}
+	return int(idx), true
+}
+
+// sliceBound converts bound to an offset into a sequence of length, clamping it
+// to the sequence. A nil or NULL bound is def.
+func sliceBound(bound object.Object, length, def int) (int, *object.Error) {
+	if bound == nil || bound == NULL {
+		return def, nil
+	}
+	integer, ok := bound.(*object.Integer)
+	if !ok {
+		return 0, newError("slice bounds must be INTEGER, got %s", bound.Type())
+	}
+
+	idx, ok := smallInt(integer)
+	if !ok {
+		// too big for an int64, so beyond one end or the other
+		if integer.Big.Sign() < 0 {
+			return 0, nil
+		}
+		return length, nil
+	}
+
+	if idx < 0 {
+		idx += int64(length)
+	}
+	switch {
+	case idx < 0:
+		return 0, nil
+	case idx > int64(length):
+		return length, nil
+	}
+	return int(idx), nil
+}
+
+func (in *Interpreter) evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := in.Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	var bounds [2]object.Object
+	for i, exp := range []ast.Expression{node.Start, node.End} {
+		if exp == nil {
+			continue
+		}
+		bounds[i] = in.Eval(exp, env)
+		if isError(bounds[i]) {
+			return bounds[i]
+		}
+	}
+
+	return sliceObject(left, bounds[0], bounds[1])
+}
+
+// sliceObject returns the elements of an array or the characters of a string
+// from start up to but not including end. Array slices share their elements
+// with the original rather than copying them.
+func sliceObject(obj, start, end object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Array:
+		from, to, err := sliceBounds(start, end, obj.Len())
+		if err != nil {
+			return err
+		}
+		return obj.Slice(from, to)
+	case *object.String:
+		runes := []rune(obj.Value)
+		from, to, err := sliceBounds(start, end, len(runes))
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: string(runes[from:to])}
+	default:
+		return newError("slice operator not supported: %s", obj.Type())
+	}
+}
+
+func sliceBounds(start, end object.Object, length int) (int, int, *object.Error) {
+	from, err := sliceBound(start, length, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := sliceBound(end, length, length)
+	if err != nil {
+		return 0, 0, err
+	}
+	if to < from {
+		to = from
+	}
+	return from, to, nil
+}
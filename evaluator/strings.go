@@ -0,0 +1,223 @@
+package evaluator
+
+import (
+	"math"
+	"monkey_interpreter/object"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringBuiltins work on strings as sequences of runes, so indexes and lengths
+// count characters rather than bytes
+var stringBuiltins = map[string]*object.Builtin{
+	"split": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("split", args)
+			if err != nil {
+				return err
+			}
+
+			parts := strings.Split(strs[0], strs[1])
+			if err := host.Allocate(len(parts)); err != nil {
+				return err
+			}
+			elements := make([]object.Object, len(parts))
+			for i, part := range parts {
+				elements[i] = &object.String{Value: part}
+			}
+			return object.NewArray(elements)
+		},
+	},
+	"join": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("first argument to `join` must be ARRAY, got %s", args[0].Type())
+			}
+			sep, ok := args[1].(*object.String)
+			if !ok {
+				return newError("second argument to `join` must be STRING, got %s", args[1].Type())
+			}
+
+			return &object.String{Value: inspectAll(arr.Elements(), sep.Value)}
+		},
+	},
+	"trim": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("trim", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.TrimSpace(strs[0])}
+		},
+	},
+	"upper": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("upper", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToUpper(strs[0])}
+		},
+	},
+	"lower": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("lower", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToLower(strs[0])}
+		},
+	},
+	"contains": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("contains", args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.Contains(strs[0], strs[1]))
+		},
+	},
+	"startsWith": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("startsWith", args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.HasPrefix(strs[0], strs[1]))
+		},
+	},
+	"endsWith": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("endsWith", args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.HasSuffix(strs[0], strs[1]))
+		},
+	},
+	"replace": &object.Builtin{
+		MinArgs: 3, MaxArgs: 3,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("replace", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}
+		},
+	},
+	"indexOf": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("indexOf", args)
+			if err != nil {
+				return err
+			}
+
+			i := strings.Index(strs[0], strs[1])
+			if i < 0 {
+				return &object.Integer{Value: -1}
+			}
+			return &object.Integer{Value: int64(utf8.RuneCountInString(strs[0][:i]))}
+		},
+	},
+	"repeat": &object.Builtin{
+		MinArgs: 2, MaxArgs: 2,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `repeat` must be STRING, got %s", args[0].Type())
+			}
+			count, ok := smallInt(args[1])
+			if !ok || count < 0 {
+				return newError("second argument to `repeat` must be a non-negative INTEGER, got %s", args[1].Inspect())
+			}
+			if len(str.Value) > 0 && count > int64(math.MaxInt/len(str.Value)) {
+				return newError("result of `repeat` is too long")
+			}
+			if err := host.Allocate(len(str.Value) * int(count)); err != nil {
+				return err
+			}
+			return &object.String{Value: strings.Repeat(str.Value, int(count))}
+		},
+	},
+	"chars": &object.Builtin{
+		MinArgs: 1, MaxArgs: 1,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			strs, err := stringArgs("chars", args)
+			if err != nil {
+				return err
+			}
+			if err := host.Allocate(utf8.RuneCountInString(strs[0])); err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, r := range strs[0] {
+				elements = append(elements, &object.String{Value: string(r)})
+			}
+			return object.NewArray(elements)
+		},
+	},
+	"substr": &object.Builtin{
+		MinArgs: 2, MaxArgs: 3,
+		Fn: func(host object.Host, args ...object.Object) object.Object {
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument to `substr` must be STRING, got %s", args[0].Type())
+			}
+			runes := []rune(str.Value)
+
+			start, ok := smallInt(args[1])
+			if !ok || start < 0 {
+				return newError("start of `substr` must be a non-negative INTEGER, got %s", args[1].Inspect())
+			}
+			end := int64(len(runes))
+			if len(args) == 3 {
+				length, ok := smallInt(args[2])
+				if !ok || length < 0 {
+					return newError("length of `substr` must be a non-negative INTEGER, got %s", args[2].Inspect())
+				}
+				if start < end && length < end-start {
+					end = start + length
+				}
+			}
+			if start >= end {
+				return &object.String{Value: ""}
+			}
+
+			return &object.String{Value: string(runes[start:end])}
+		},
+	},
+}
+
+// stringArgs checks that args are strings and returns their values
+func stringArgs(name string, args []object.Object) ([]string, *object.Error) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return nil, newError("arguments to `%s` must be STRING, got %s", name, arg.Type())
+		}
+		strs[i] = str.Value
+	}
+	return strs, nil
+}
+
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx, ok := sequenceIndex(index, len(runes))
+	if !ok {
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[idx])}
+}
@@ -0,0 +1,99 @@
+// Tests for the prelude. TestPrelude calls every function whose name starts
+// with test and expects it to return true.
+
+// eq reports whether two arrays of integers or booleans hold the same elements
+let eq = fn(a, b) {
+    if (len(a) == len(b)) {
+        all(zip(a, b), fn(pair) { pair[0] == pair[1] })
+    } else {
+        false
+    }
+};
+
+// both is a logical and, which Monkey lacks
+let both = fn(a, b) {
+    if (a) { b } else { false }
+};
+
+let isEven = fn(x) { x / 2 * 2 == x };
+
+let testMap = fn() {
+    both(eq(map([1, 2, 3], fn(x) { x * 2 }), [2, 4, 6]), eq(map([], fn(x) { x }), []))
+};
+
+let testFilter = fn() {
+    both(eq(filter([1, 2, 3, 4], isEven), [2, 4]), eq(filter([1, 3], isEven), []))
+};
+
+let testReduce = fn() {
+    both(reduce([1, 2, 3], 10, fn(acc, x) { acc - x }) == 4, reduce([], 7, fn(acc, x) { x }) == 7)
+};
+
+let testZip = fn() {
+    let pairs = zip([1, 2, 3], [4, 5]);
+    both(len(pairs) == 2, both(eq(pairs[0], [1, 4]), eq(pairs[1], [2, 5])))
+};
+
+let testAnyAll = fn() {
+    let checks = [
+        any([1, 2, 3], isEven),
+        !any([1, 3], isEven),
+        !any([], isEven),
+        all([2, 4], isEven),
+        !all([2, 3], isEven),
+        all([], isEven)
+    ];
+    eq(checks, [true, true, true, true, true, true])
+};
+
+let testReverseConcat = fn() {
+    both(eq(reverse([1, 2, 3]), [3, 2, 1]), eq(concat([1], [2, 3]), [1, 2, 3]))
+};
+
+let testZipIterators = fn() {
+    let pairs = zip(range(10), map(range(1000000000), fn(x) { x * x }));
+    both(len(pairs) == 10, both(eq(pairs[9], [9, 81]), eq(zip(range(3), []), [])))
+};
+
+let testIterators = fn() {
+    let checks = [
+        any(range(1000000000), fn(x) { x == 3 }),
+        !all(range(1000000000), fn(x) { x < 3 }),
+        eq(reverse(range(3)), [2, 1, 0]),
+        eq(concat(range(2), filter(range(5), fn(x) { x > 2 })), [0, 1, 3, 4])
+    ];
+    eq(checks, [true, true, true, true])
+};
+
+// Arrays longer than the default call depth limit
+let testLargeInputs = fn() {
+    let n = 20000;
+    let arr = collect(range(n));
+    let checks = [
+        any(arr, fn(x) { x == n - 1 }),
+        all(arr, fn(x) { x < n }),
+        len(zip(arr, arr)) == n,
+        eq(reverse(arr), collect(range(n - 1, -1, -1))),
+        len(concat(arr, arr)) == 2 * n
+    ];
+    eq(checks, [true, true, true, true, true])
+};
+
+let testRange = fn() {
+    both(eq(collect(range(2, 5)), [2, 3, 4]), eq(collect(range(3, 3)), []))
+};
+
+let testSum = fn() {
+    both(sum(range(1, 101)) == 5050, sum([]) == 0)
+};
+
+let testSortBy = fn() {
+    let people = [[3, 30], [1, 10], [2, 20], [1, 11]];
+    let sorted = sortBy(people, first);
+    both(eq(map(sorted, last), [10, 11, 20, 30]), eq(sortBy([5, -1, 3], fn(x) { 0 - x }), [5, 3, -1]))
+};
+
+let testShadowing = fn() {
+    let map = fn(arr, f) { 42 };
+    map([1], fn(x) { x }) == 42
+};
@@ -0,0 +1,499 @@
+package format
+
+import (
+	"bytes"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/token"
+	"strings"
+)
+
+// Config controls the layout of formatted source
+type Config struct {
+	Indent string // written once per nesting level
+}
+
+// Default is the configuration used when none is given
+var Default = Config{Indent: "    "}
+
+// precedences mirrors the parser's binding power of infix operators so that
+// only the parentheses needed to keep the tree intact are printed
+var precedences = map[string]int{
+	"==": 1,
+	"!=": 1,
+	"<":  2,
+	">":  2,
+	"in": 2,
+	"+":  3,
+	"-":  3,
+	"*":  4,
+	"/":  4,
+}
+
+// escaper writes string values back as the escape sequences the lexer reads
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`, "${", `\${`)
+
+type printer struct {
+	config   Config
+	out      bytes.Buffer
+	depth    int
+	comments []token.Token
+	lastLine int // source line of the last thing written, used to keep blank lines
+}
+
+// Node formats a single node as Monkey source
+func Node(node ast.Node, config Config) string {
+	p := &printer{config: config}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		p.statements(node.Statements, false)
+	case ast.Statement:
+		p.statement(node, false)
+	case ast.Expression:
+		p.expression(node)
+	}
+
+	return p.out.String()
+}
+
+// Source parses input and reformats it, keeping its comments. The parser
+// errors are returned instead when input does not parse.
+func Source(input string, config Config) (string, []string) {
+	l := lexer.New(input)
+	par := parser.New(l)
+	program := par.ParseProgram()
+	if len(par.Errors()) != 0 {
+		return "", par.Errors()
+	}
+
+	p := &printer{config: config, comments: l.Comments()}
+	p.statements(program.Statements, false)
+	p.flushComments(-1)
+
+	return p.out.String(), nil
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.write("\n")
+	p.write(strings.Repeat(p.config.Indent, p.depth))
+}
+
+// startLine begins a new output line for something found at the given source
+// line, keeping a single blank line where the source had one or more.
+func (p *printer) startLine(line int) {
+	if p.out.Len() > 0 {
+		if p.lastLine > 0 && line > p.lastLine+1 {
+			p.write("\n")
+		}
+		p.newline()
+	} else {
+		p.write(strings.Repeat(p.config.Indent, p.depth))
+	}
+}
+
+// flushComments writes the pending comments that appear before line on their
+// own lines. A negative line flushes every remaining comment.
+func (p *printer) flushComments(line int) {
+	for len(p.comments) > 0 && (line < 0 || p.comments[0].Line < line) {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		p.startLine(c.Line)
+		p.write(c.Literal)
+		p.lastLine = c.Line
+	}
+	if line < 0 && p.out.Len() > 0 {
+		p.write("\n")
+	}
+}
+
+// trailingComment appends a pending comment found on line to the current output line
+func (p *printer) trailingComment(line int) {
+	if len(p.comments) > 0 && p.comments[0].Line == line {
+		p.write(" " + p.comments[0].Literal)
+		p.comments = p.comments[1:]
+	}
+}
+
+// statements writes stmts one per line; inBlock is set for the body of a block
+// whose last expression is its value and so needs no semicolon
+func (p *printer) statements(stmts []ast.Statement, inBlock bool) {
+	for i, s := range stmts {
+		line := ast.TokenOf(s).Line
+		p.flushComments(line)
+		p.startLine(line)
+		p.statement(s, inBlock && i == len(stmts)-1)
+		p.trailingComment(line)
+		p.lastLine = lastLine(s)
+	}
+}
+
+func (p *printer) statement(s ast.Statement, last bool) {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		p.write("let ")
+		if s.Pattern != nil {
+			p.pattern(s.Pattern)
+		} else {
+			p.write(s.Name.Value)
+		}
+		if s.Type != nil {
+			p.write(": " + s.Type.String())
+		}
+		p.write(" = ")
+		p.expression(s.Value)
+		p.write(";")
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expression(s.ReturnValue)
+		p.write(";")
+	case *ast.YieldStatement:
+		p.write("yield ")
+		p.expression(s.Value)
+		p.write(";")
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression)
+		if !last && !endsWithBlock(s.Expression) {
+			p.write(";")
+		}
+	case *ast.BlockStatement:
+		p.block(s)
+	}
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	if len(b.Statements) == 0 && !p.hasCommentsBefore(b.Rbrace.Line) {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.depth++
+	p.lastLine = b.Token.Line
+	p.trailingComment(b.Token.Line)
+	p.statements(b.Statements, true)
+	if b.Rbrace.Line > 0 {
+		p.flushComments(b.Rbrace.Line)
+	}
+	p.depth--
+	p.newline()
+	p.write("}")
+}
+
+func (p *printer) hasCommentsBefore(line int) bool {
+	return len(p.comments) > 0 && p.comments[0].Line < line
+}
+
+func (p *printer) expression(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		p.write(exp.Value)
+	case *ast.IntegerLiteral:
+		p.write(exp.Token.Literal)
+	case *ast.FloatLiteral:
+		p.write(exp.Token.Literal)
+	case *ast.Boolean:
+		p.write(exp.Token.Literal)
+	case *ast.StringLiteral:
+		p.write(`"` + escaper.Replace(exp.Value) + `"`)
+
+	case *ast.InterpolatedString:
+		p.write(`"`)
+		for i, part := range exp.Parts {
+			if i%2 == 0 {
+				p.write(escaper.Replace(part.(*ast.StringLiteral).Value))
+			} else {
+				p.write("${")
+				p.expression(part)
+				p.write("}")
+			}
+		}
+		p.write(`"`)
+
+	case *ast.PrefixExpression:
+		p.write(exp.Operator)
+		p.operand(exp.Right, needsParens(exp.Right, len(precedences)+1))
+
+	case *ast.InfixExpression:
+		prec := precedence(exp.Operator)
+		p.operand(exp.Left, needsParens(exp.Left, prec))
+		p.write(" " + exp.Operator + " ")
+		p.operand(exp.Right, needsParens(exp.Right, prec+1))
+
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(exp.Condition)
+		p.write(") ")
+		p.block(exp.Consequence)
+		if exp.Alternative != nil {
+			p.write(" else ")
+			p.block(exp.Alternative)
+		}
+
+	case *ast.ForExpression:
+		p.write("for (")
+		p.pattern(exp.Pattern)
+		p.write(" in ")
+		p.expression(exp.Iterable)
+		p.write(") ")
+		p.block(exp.Body)
+
+	case *ast.FunctionLiteral:
+		p.write("fn(")
+		for i, param := range exp.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+			if exp.Variadic && i == len(exp.Parameters)-1 {
+				p.write("...")
+			}
+			if pattern := exp.ParameterPattern(i); pattern != nil {
+				p.pattern(pattern)
+			} else {
+				p.write(param.Value)
+			}
+			if t := exp.ParameterType(i); t != nil {
+				p.write(": " + t.String())
+			}
+			if d := exp.ParameterDefault(i); d != nil {
+				p.write(" = ")
+				p.expression(d)
+			}
+		}
+		p.write(") ")
+		if exp.ReturnType != nil {
+			p.write("-> " + exp.ReturnType.String() + " ")
+		}
+		p.block(exp.Body)
+
+	case *ast.CallExpression:
+		p.operand(exp.Function, isOperator(exp.Function))
+		p.write("(")
+		p.list(exp.Arguments)
+		p.write(")")
+
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.list(exp.Elements)
+		p.write("]")
+
+	case *ast.IndexExpression:
+		p.operand(exp.Left, isOperator(exp.Left))
+		p.write("[")
+		p.expression(exp.Index)
+		p.write("]")
+
+	case *ast.SliceExpression:
+		p.operand(exp.Left, isOperator(exp.Left))
+		p.write("[")
+		if exp.Start != nil {
+			p.expression(exp.Start)
+		}
+		p.write(":")
+		if exp.End != nil {
+			p.expression(exp.End)
+		}
+		p.write("]")
+
+	case *ast.SpreadExpression:
+		p.write("...")
+		p.expression(exp.Value)
+
+	case *ast.NamedArgument:
+		p.write(exp.Name.Value + ": ")
+		p.expression(exp.Value)
+
+	case *ast.MemberExpression:
+		p.operand(exp.Object, isOperator(exp.Object))
+		p.write("." + exp.Property.Value)
+
+	case *ast.HashLiteral:
+		p.write("{")
+		for i, key := range exp.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(key)
+			p.write(": ")
+			p.expression(exp.Pairs[key])
+		}
+		p.write("}")
+
+	case *ast.MatchExpression:
+		p.write("match (")
+		p.expression(exp.Subject)
+		p.write(") ")
+		p.arms(exp)
+	}
+}
+
+// arms writes the arms of a match one per line, each followed by a comma
+func (p *printer) arms(exp *ast.MatchExpression) {
+	if len(exp.Arms) == 0 {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.depth++
+	p.lastLine = exp.Token.Line
+	for _, arm := range exp.Arms {
+		line := ast.TokenOf(arm).Line
+		p.flushComments(line)
+		p.startLine(line)
+		p.pattern(arm.Pattern)
+		if arm.Guard != nil {
+			p.write(" if ")
+			p.expression(arm.Guard)
+		}
+		p.write(" => ")
+		p.expression(arm.Result)
+		p.write(",")
+		p.trailingComment(line)
+		p.lastLine = lastLine(arm)
+	}
+	p.depth--
+	p.newline()
+	p.write("}")
+}
+
+func (p *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.LiteralPattern:
+		p.expression(pattern.Value)
+	case *ast.WildcardPattern:
+		p.write("_")
+	case *ast.BindingPattern:
+		p.write(pattern.Name.Value)
+
+	case *ast.ArrayPattern:
+		p.write("[")
+		for i, el := range pattern.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.pattern(el)
+		}
+		if pattern.Rest != nil {
+			if len(pattern.Elements) > 0 {
+				p.write(", ")
+			}
+			p.write("...")
+			p.pattern(pattern.Rest)
+		}
+		p.write("]")
+
+	case *ast.HashPattern:
+		p.write("{")
+		for i, key := range pattern.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			// {name} is short for {"name": name}, and {name = x} for {"name": name = x}
+			value := pattern.Values[i]
+			dp, hasDefault := value.(*ast.DefaultPattern)
+			if hasDefault {
+				value = dp.Pattern
+			}
+			str, isString := key.(*ast.StringLiteral)
+			binding, isBinding := value.(*ast.BindingPattern)
+			if isString && isBinding && str.Value == binding.Name.Value {
+				p.write(str.Value)
+				if hasDefault {
+					p.write(" = ")
+					p.expression(dp.Default)
+				}
+				continue
+			}
+			p.expression(key)
+			p.write(": ")
+			p.pattern(pattern.Values[i])
+		}
+		p.write("}")
+
+	case *ast.DefaultPattern:
+		p.pattern(pattern.Pattern)
+		p.write(" = ")
+		p.expression(pattern.Default)
+	}
+}
+
+func (p *printer) operand(exp ast.Expression, parens bool) {
+	if parens {
+		p.write("(")
+	}
+	p.expression(exp)
+	if parens {
+		p.write(")")
+	}
+}
+
+func (p *printer) list(exps []ast.Expression) {
+	for i, exp := range exps {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(exp)
+	}
+}
+
+func precedence(operator string) int {
+	if prec, ok := precedences[operator]; ok {
+		return prec
+	}
+	return 0
+}
+
+// needsParens reports whether exp must be wrapped when it is the operand of
+// an operator that binds with at least min
+func needsParens(exp ast.Expression, min int) bool {
+	if infix, ok := exp.(*ast.InfixExpression); ok {
+		return precedence(infix.Operator) < min
+	}
+	return false
+}
+
+// endsWithBlock reports whether exp is an if or for, which need no semicolon
+// after them as statements
+func endsWithBlock(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.IfExpression, *ast.ForExpression:
+		return true
+	}
+	return false
+}
+
+func isOperator(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.PrefixExpression, *ast.InfixExpression:
+		return true
+	}
+	return false
+}
+
+// lastLine approximates the source line a node ends on
+func lastLine(node ast.Node) int {
+	line := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if l := ast.TokenOf(n).Line; l > line {
+			line = l
+		}
+		switch n := n.(type) {
+		case *ast.BlockStatement:
+			if n.Rbrace.Line > line {
+				line = n.Rbrace.Line
+			}
+		case *ast.MatchExpression:
+			if n.Rbrace.Line > line {
+				line = n.Rbrace.Line
+			}
+		}
+		return true
+	})
+	return line
+}
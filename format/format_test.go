@@ -0,0 +1,96 @@
+package format
+
+import (
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/parser"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=5 ;x", "let x = 5;\nx;\n"},
+		{"1 + (2 * 3); (1 + 2) * 3; 1 - (2 - 3); (1 - 2) - 3;", "1 + 2 * 3;\n(1 + 2) * 3;\n1 - (2 - 3);\n1 - 2 - 3;\n"},
+		{"-(1 + 2); !true", "-(1 + 2);\n!true;\n"},
+		{`let add = fn(a,b){return a+b;}; add(1,2)`, "let add = fn(a, b) {\n    return a + b;\n};\nadd(1, 2);\n"},
+		{"if (x) { 1 } else { 2 }", "if (x) {\n    1\n} else {\n    2\n}\n"},
+		{`{"b": 1, "a": [1, 2][0]}`, "{\"b\": 1, \"a\": [1, 2][0]};\n"},
+		{"let f = fn() {};", "let f = fn() {};\n"},
+		{"let x = 1;\n\n\nlet y = 2;", "let x = 1;\n\nlet y = 2;\n"},
+		{
+			"// header\nlet x = 1; // one\nlet f = fn() {\n  // inside\n  x\n};",
+			"// header\nlet x = 1; // one\nlet f = fn() {\n    // inside\n    x\n};\n",
+		},
+		{"let f = fn() {\n  x;\n  // done\n};", "let f = fn() {\n    x\n    // done\n};\n"},
+		{"let x:int=1; let f = fn(a:int,b)->[string]{[a]};", "let x: int = 1;\nlet f = fn(a: int, b) -> [string] {\n    [a]\n};\n"},
+		{
+			`let v = match (x) {[a,...b] if a>0=>a, {"k":k, name}=>k, -1=>0, _=>1};`,
+			"let v = match (x) {\n    [a, ...b] if a > 0 => a,\n    {k, name} => k,\n    -1 => 0,\n    _ => 1,\n};\n",
+		},
+		{
+			`let {name,"pos":[x,y=0],size=1}=p; let f=fn([a,...b],{c}:{string: int}){a};`,
+			"let {name, \"pos\": [x, y = 0], size = 1} = p;\nlet f = fn([a, ...b], {c}: {string: int}) {\n    a\n};\n",
+		},
+		{"let f=fn(x:int=1,...rest){f(...rest,x:x)};", "let f = fn(x: int = 1, ...rest) {\n    f(...rest, x: x)\n};\n"},
+		{`"a ${x+1} \${y} ${ "\"${z}\"" }"`, "\"a ${x + 1} \\${y} ${\"\\\"${z}\\\"\"}\";\n"},
+		{"let g=fn(xs){for([k,v] in xs){yield k+v;}};for(x in g(xs)){puts(x)} 1.5e3", "let g = fn(xs) {\n    for ([k, v] in xs) {\n        yield k + v;\n    }\n};\nfor (x in g(xs)) {\n    puts(x)\n}\n1.5e3;\n"},
+		{`puts("a\tb\n", "\"q\" \\")`, "puts(\"a\\tb\\n\", \"\\\"q\\\" \\\\\");\n"},
+	}
+
+	for _, tt := range tests {
+		formatted, errors := Source(tt.input, Default)
+		if len(errors) != 0 {
+			t.Errorf("unexpected parser errors for %q: %v", tt.input, errors)
+			continue
+		}
+		if formatted != tt.expected {
+			t.Errorf("wrong formatting for %q.\nwant=%q\ngot= %q", tt.input, tt.expected, formatted)
+		}
+	}
+}
+
+func TestSourcePreservesSemantics(t *testing.T) {
+	inputs := []string{
+		"let a = 1 + 2 * 3 - 4 / 2 == 5 != (6 < 7);",
+		"let f = fn(x, y) { if (x > y) { return x; } else { y } }; f(1, 2)(3);",
+		"-a * b; !(-a); a + b + c; a + (b + c); a * (b + c) * d;",
+		`let h = {"one": [1, 2][-1 + 1], true: fn() { 1 }()}; h["one"];`,
+		"math.sqrt(2.25) * -1.5 + (-h).x.y;",
+		"a[1:b+1][: -1][2 :][:];",
+		"let v = match (x) { 1 => 2, _ => match (x) { y => y } }; let w = v;\nv",
+	}
+
+	for _, input := range inputs {
+		formatted, errors := Source(input, Default)
+		if len(errors) != 0 {
+			t.Fatalf("unexpected parser errors for %q: %v", input, errors)
+		}
+
+		if parse(t, formatted) != parse(t, input) {
+			t.Errorf("formatting changed the program.\ninput=%q\nformatted=%q", input, formatted)
+		}
+
+		again, _ := Source(formatted, Default)
+		if again != formatted {
+			t.Errorf("formatting is not stable.\nfirst=%q\nsecond=%q", formatted, again)
+		}
+	}
+}
+
+func TestSourceParserErrors(t *testing.T) {
+	_, errors := Source("let = ;", Default)
+	if len(errors) == 0 {
+		t.Errorf("expected parser errors")
+	}
+}
+
+func parse(t *testing.T, input string) string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program.String()
+}
@@ -1,6 +1,9 @@
 package lexer
 
-import "monkey_interpreter/token"
+import (
+	"monkey_interpreter/token"
+	"strings"
+)
 
 // Lexer defines the Lexer structure which is then tokenized
 type Lexer struct {
@@ -8,16 +11,28 @@ type Lexer struct {
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	ch           byte // current char under examination
+	line         int  // line of the current char
+	column       int  // column of the current char
+	comments     []token.Token
+	// interpolations has an entry for each ${ of a string not yet closed,
+	// counting the braces opened inside it
+	interpolations []int
 }
 
 // New function returns a pointer to a Lexer object
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -33,6 +48,8 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 	l.skipWhitespace()
 
+	line, column := l.line, l.column
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -40,13 +57,25 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.EQ, Literal: literal}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.FAT_ARROW, Literal: literal}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ARROW, Literal: literal}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -74,34 +103,56 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RPAREN, l.ch)
 	case '{':
 		tok = newToken(token.LBRACE, l.ch)
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1]++
+		}
 	case '}':
-		tok = newToken(token.RBRACE, l.ch)
+		n := len(l.interpolations)
+		switch {
+		case n > 0 && l.interpolations[n-1] == 0:
+			l.interpolations = l.interpolations[:n-1]
+			tok = l.readStringPart(token.STRING_MIDDLE, token.STRING_END)
+		case n > 0:
+			l.interpolations[n-1]--
+			tok = newToken(token.RBRACE, l.ch)
+		default:
+			tok = newToken(token.RBRACE, l.ch)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.readStringPart(token.STRING_START, token.STRING)
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if strings.HasPrefix(l.input[l.position:], "...") {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.DOT, l.ch)
+		}
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Line, tok.Column = line, column
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
+			tok.Line, tok.Column = line, column
 			return tok
 		}
 		tok = newToken(token.ILLEGAL, l.ch)
 	}
 
 	l.readChar()
+	tok.Line, tok.Column = line, column
 	return tok
 }
 
@@ -122,17 +173,78 @@ func isLetter(ch byte) bool {
 }
 
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r':
+			l.readChar()
+		case l.ch == '/' && l.peekChar() == '/':
+			l.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// skipComment skips a // comment up to the end of the line, remembering it for Comments
+func (l *Lexer) skipComment() {
+	tok := token.Token{Type: token.COMMENT, Line: l.line, Column: l.column}
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
+	tok.Literal = l.input[position:l.position]
+	l.comments = append(l.comments, tok)
 }
 
-func (l *Lexer) readNumber() string {
+// Comments returns the comments skipped so far
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+// readNumber reads an integer, or a float when the digits are followed by a
+// dot and more digits, an exponent such as e9 or e-3, or both
+func (l *Lexer) readNumber() (token.TokenType, string) {
 	position := l.position
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+
+	tokenType := token.TokenType(token.INT)
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		l.readChar()
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+		tokenType = token.FLOAT
+	}
+	if l.readExponent() {
+		tokenType = token.FLOAT
+	}
+	return tokenType, l.input[position:l.position]
+}
+
+// readExponent reads the e or E, optional sign and digits that may end a
+// number, reporting whether there were any. A letter e not followed by digits
+// is left to start an identifier.
+func (l *Lexer) readExponent() bool {
+	if l.ch != 'e' && l.ch != 'E' {
+		return false
+	}
+	digit := l.readPosition
+	if next := l.peekChar(); next == '+' || next == '-' {
+		digit++
+	}
+	if digit >= len(l.input) || !isDigit(l.input[digit]) {
+		return false
+	}
+
+	for l.readPosition <= digit {
+		l.readChar()
+	}
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+	return true
 }
 
 func isDigit(ch byte) bool {
@@ -146,13 +258,46 @@ func (l *Lexer) peekChar() byte {
 	return l.input[l.readPosition]
 }
 
-func (l *Lexer) readString() string {
-	position := l.position + 1
+// readStringPart reads up to the closing quote, replacing the escape
+// sequences \n, \t, \r, \", \\ and \$. Other backslashes are kept as they
+// are. When it comes to a ${ first, it stops there and returns a token of type
+// open, expecting the tokens of an expression and a } to follow; otherwise the
+// token has type closed.
+func (l *Lexer) readStringPart(open, closed token.TokenType) token.Token {
+	var out strings.Builder
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+		switch l.ch {
+		case '"', 0:
+			return token.Token{Type: closed, Literal: out.String()}
+		case '$':
+			if l.peekChar() != '{' {
+				out.WriteByte(l.ch)
+				continue
+			}
+			l.readChar()
+			l.interpolations = append(l.interpolations, 0)
+			return token.Token{Type: open, Literal: out.String()}
+		case '\\':
+			l.readChar()
+			switch l.ch {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case 'r':
+				out.WriteByte('\r')
+			case '"', '\\', '$':
+				out.WriteByte(l.ch)
+			case 0:
+				out.WriteByte('\\')
+				return token.Token{Type: closed, Literal: out.String()}
+			default:
+				out.WriteByte('\\')
+				out.WriteByte(l.ch)
+			}
+		default:
+			out.WriteByte(l.ch)
 		}
 	}
-	return l.input[position:l.position]
 }
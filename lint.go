@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"monkey_interpreter/lint"
+	"os"
+	"strings"
+)
+
+// runLint implements the lint subcommand and returns the process exit code
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	disable := flags.String("disable", "", "comma separated rule IDs to skip ("+strings.Join(lint.Rules, ", ")+")")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey lint [-disable rules] file...\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	config := lint.Config{Disabled: make(map[string]bool)}
+	for _, rule := range splitList(*disable) {
+		config.Disabled[rule] = true
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		findings, errors := lint.Source(string(source), config)
+		for _, msg := range errors {
+			fmt.Printf("%s: parser error: %s\n", path, msg)
+			status = 1
+		}
+		for _, f := range findings {
+			fmt.Printf("%s:%s\n", path, f)
+			status = 1
+		}
+	}
+
+	return status
+}
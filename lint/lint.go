@@ -0,0 +1,423 @@
+package lint
+
+import (
+	"fmt"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/token"
+	"sort"
+	"strings"
+)
+
+// Rule IDs reported by the linter
+const (
+	UnusedBinding = "unused-binding"
+	UnusedParam   = "unused-param"
+	Shadow        = "shadow"
+	Unreachable   = "unreachable"
+	Undefined     = "undefined"
+	ArgCount      = "arg-count"
+	IfValue       = "if-value"
+)
+
+// Rules lists every rule ID the linter knows about
+var Rules = []string{UnusedBinding, UnusedParam, Shadow, Unreachable, Undefined, ArgCount, IfValue}
+
+// Finding is a single problem reported by the linter
+type Finding struct {
+	Rule    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s [%s]", f.Line, f.Column, f.Message, f.Rule)
+}
+
+// Config controls which rules are reported
+type Config struct {
+	Disabled map[string]bool
+}
+
+// binding is a name introduced by a let statement or a function parameter
+type binding struct {
+	ident *ast.Identifier
+	param bool
+	used  bool
+	fn    *ast.FunctionLiteral // the function bound, if a literal
+}
+
+// scope is the set of bindings of a program or function body. Blocks do not
+// open a new scope because the evaluator runs them in the enclosing environment.
+type scope struct {
+	names   map[string]*binding
+	order   []*binding
+	outer   *scope
+	pending []*ast.FunctionLiteral
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{names: make(map[string]*binding), outer: outer}
+}
+
+// lookup searches s and its enclosing scopes; it is safe to call on a nil scope
+func (s *scope) lookup(name string) (*binding, bool) {
+	for cur := s; cur != nil; cur = cur.outer {
+		if b, ok := cur.names[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+type linter struct {
+	config   Config
+	findings []Finding
+}
+
+// Lint walks program and returns its findings ordered by position
+func Lint(program *ast.Program, config Config) []Finding {
+	l := &linter{config: config}
+
+	s := newScope(nil)
+	l.statements(program.Statements, s)
+	l.closeScope(s)
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i], l.findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return l.findings
+}
+
+// Source parses input and lints it. Parser errors are returned instead of
+// findings when the input does not parse. A `// lint:ignore rule` comment
+// suppresses the listed rules, or every rule when none are listed, on the line
+// it trails or, when it stands alone, on the line after it.
+func Source(input string, config Config) ([]Finding, []string) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, p.Errors()
+	}
+
+	ignored := suppressions(input)
+	findings := []Finding{}
+	for _, f := range Lint(program, config) {
+		if rules := ignored[f.Line]; rules["*"] || rules[f.Rule] {
+			continue
+		}
+		findings = append(findings, f)
+	}
+
+	return findings, nil
+}
+
+func suppressions(input string) map[int]map[string]bool {
+	ignored := make(map[int]map[string]bool)
+
+	for i, line := range strings.Split(input, "\n") {
+		comment := strings.Index(line, "//")
+		if comment < 0 {
+			continue
+		}
+		text := strings.TrimSpace(line[comment+2:])
+		if !strings.HasPrefix(text, "lint:ignore") {
+			continue
+		}
+
+		rules := strings.FieldsFunc(text[len("lint:ignore"):], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(rules) == 0 {
+			rules = []string{"*"}
+		}
+		target := i + 1
+		if strings.TrimSpace(line[:comment]) == "" {
+			target++
+		}
+		if ignored[target] == nil {
+			ignored[target] = make(map[string]bool)
+		}
+		for _, rule := range rules {
+			ignored[target][rule] = true
+		}
+	}
+
+	return ignored
+}
+
+func (l *linter) report(rule string, tok token.Token, format string, a ...interface{}) {
+	if l.config.Disabled[rule] {
+		return
+	}
+	l.findings = append(l.findings, Finding{
+		Rule:    rule,
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (l *linter) declare(s *scope, ident *ast.Identifier, param bool, value ast.Expression) {
+	if outer, ok := s.outer.lookup(ident.Value); ok {
+		tok := outer.ident.Token
+		l.report(Shadow, ident.Token, "%s shadows the binding declared at %d:%d", ident.Value, tok.Line, tok.Column)
+	} else if _, ok := s.names[ident.Value]; !ok && evaluator.IsBuiltin(ident.Value) {
+		l.report(Shadow, ident.Token, "%s shadows the builtin function of the same name", ident.Value)
+	}
+
+	b := &binding{ident: ident, param: param}
+	b.fn, _ = value.(*ast.FunctionLiteral)
+
+	s.names[ident.Value] = b
+	s.order = append(s.order, b)
+}
+
+// closeScope checks the function literals deferred while walking the scope,
+// now that every binding they could refer to is known, then reports unused bindings.
+func (l *linter) closeScope(s *scope) {
+	for len(s.pending) > 0 {
+		fn := s.pending[0]
+		s.pending = s.pending[1:]
+		l.function(fn, s)
+	}
+
+	for _, b := range s.order {
+		if b.used || strings.HasPrefix(b.ident.Value, "_") {
+			continue
+		}
+		if b.param {
+			l.report(UnusedParam, b.ident.Token, "parameter %s is never used", b.ident.Value)
+		} else {
+			l.report(UnusedBinding, b.ident.Token, "%s is declared but never used", b.ident.Value)
+		}
+	}
+}
+
+func (l *linter) function(fn *ast.FunctionLiteral, outer *scope) {
+	s := newScope(outer)
+	for i, param := range fn.Parameters {
+		l.expression(fn.ParameterDefault(i), s, true)
+		if pattern := fn.ParameterPattern(i); pattern != nil {
+			l.pattern(pattern, s, true)
+		} else {
+			l.declare(s, param, true, nil)
+		}
+	}
+	if fn.Body != nil {
+		l.statements(fn.Body.Statements, s)
+	}
+	l.closeScope(s)
+}
+
+// pattern walks the defaults in p, which are evaluated before anything is
+// bound, then declares the names p binds
+func (l *linter) pattern(p ast.Pattern, s *scope, param bool) {
+	l.defaults(p, s)
+	for _, name := range ast.PatternNames(p) {
+		l.declare(s, name, param, nil)
+	}
+}
+
+func (l *linter) defaults(p ast.Pattern, s *scope) {
+	switch p := p.(type) {
+	case *ast.ArrayPattern:
+		for _, el := range p.Elements {
+			l.defaults(el, s)
+		}
+		if p.Rest != nil {
+			l.defaults(p.Rest, s)
+		}
+	case *ast.HashPattern:
+		for _, v := range p.Values {
+			l.defaults(v, s)
+		}
+	case *ast.DefaultPattern:
+		l.defaults(p.Pattern, s)
+		l.expression(p.Default, s, true)
+	}
+}
+
+func (l *linter) statements(stmts []ast.Statement, s *scope) {
+	for i, stmt := range stmts {
+		if i > 0 {
+			if _, ok := stmts[i-1].(*ast.ReturnStatement); ok {
+				l.report(Unreachable, ast.TokenOf(stmt), "unreachable statement after return")
+			}
+		}
+		l.statement(stmt, s)
+	}
+}
+
+func (l *linter) statement(stmt ast.Statement, s *scope) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		l.expression(stmt.Value, s, true)
+		if stmt.Pattern != nil {
+			l.pattern(stmt.Pattern, s, false)
+		} else {
+			l.declare(s, stmt.Name, false, stmt.Value)
+		}
+	case *ast.ReturnStatement:
+		l.expression(stmt.ReturnValue, s, true)
+	case *ast.YieldStatement:
+		l.expression(stmt.Value, s, true)
+	case *ast.ExpressionStatement:
+		l.expression(stmt.Expression, s, false)
+	case *ast.BlockStatement:
+		l.statements(stmt.Statements, s)
+	}
+}
+
+// expression walks exp; asValue is set when the result of exp is consumed
+func (l *linter) expression(exp ast.Expression, s *scope, asValue bool) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		l.resolve(exp, s, "undefined identifier: %s")
+
+	case *ast.PrefixExpression:
+		l.expression(exp.Right, s, true)
+
+	case *ast.InfixExpression:
+		l.expression(exp.Left, s, true)
+		l.expression(exp.Right, s, true)
+
+	case *ast.IfExpression:
+		if asValue && exp.Alternative == nil {
+			l.report(IfValue, exp.Token, "if without else used as a value")
+		}
+		l.expression(exp.Condition, s, true)
+		if exp.Consequence != nil {
+			l.statements(exp.Consequence.Statements, s)
+		}
+		if exp.Alternative != nil {
+			l.statements(exp.Alternative.Statements, s)
+		}
+
+	case *ast.ForExpression:
+		l.expression(exp.Iterable, s, true)
+		l.pattern(exp.Pattern, s, false)
+		if exp.Body != nil {
+			l.statements(exp.Body.Statements, s)
+		}
+
+	case *ast.FunctionLiteral:
+		s.pending = append(s.pending, exp)
+
+	case *ast.CallExpression:
+		l.call(exp, s)
+
+	case *ast.ArrayLiteral:
+		for _, el := range exp.Elements {
+			l.expression(el, s, true)
+		}
+
+	case *ast.IndexExpression:
+		l.expression(exp.Left, s, true)
+		l.expression(exp.Index, s, true)
+
+	case *ast.SliceExpression:
+		l.expression(exp.Left, s, true)
+		l.expression(exp.Start, s, true)
+		l.expression(exp.End, s, true)
+
+	case *ast.InterpolatedString:
+		for _, part := range exp.Parts {
+			l.expression(part, s, true)
+		}
+
+	case *ast.SpreadExpression:
+		l.expression(exp.Value, s, true)
+
+	case *ast.NamedArgument:
+		l.expression(exp.Value, s, true)
+
+	case *ast.MemberExpression:
+		l.expression(exp.Object, s, true)
+
+	case *ast.HashLiteral:
+		for _, key := range exp.Keys {
+			l.expression(key, s, true)
+			l.expression(exp.Pairs[key], s, true)
+		}
+
+	case *ast.MatchExpression:
+		l.expression(exp.Subject, s, true)
+		for _, arm := range exp.Arms {
+			l.pattern(arm.Pattern, s, false)
+			l.expression(arm.Guard, s, true)
+			l.expression(arm.Result, s, asValue)
+		}
+	}
+}
+
+func (l *linter) call(exp *ast.CallExpression, s *scope) {
+	for _, arg := range exp.Arguments {
+		l.expression(arg, s, true)
+	}
+
+	name := exp.Function.String()
+	var fn *ast.FunctionLiteral
+	min, max := -1, -1
+
+	switch f := exp.Function.(type) {
+	case *ast.Identifier:
+		b, ok := l.resolve(f, s, "call to undefined function %s")
+		if ok {
+			fn = b.fn
+		} else if lo, hi, ok := evaluator.BuiltinArity(f.Value); ok {
+			min, max = lo, hi
+		}
+	case *ast.FunctionLiteral:
+		name = "function literal"
+		fn = f
+		l.expression(f, s, true)
+	default:
+		l.expression(exp.Function, s, true)
+	}
+
+	if fn != nil {
+		min, max = fn.Required(), len(fn.Parameters)
+		if fn.Variadic {
+			max = -1
+		}
+	}
+	for _, arg := range exp.Arguments {
+		if _, ok := arg.(*ast.SpreadExpression); ok {
+			// the number of arguments is not known until it runs
+			return
+		}
+	}
+
+	got := len(exp.Arguments)
+	switch {
+	case min < 0 || got >= min && (max < 0 || got <= max):
+	case min == max:
+		l.report(ArgCount, ast.TokenOf(exp.Function), "%s expects %d arguments, got %d", name, min, got)
+	case max < 0:
+		l.report(ArgCount, ast.TokenOf(exp.Function), "%s expects at least %d arguments, got %d", name, min, got)
+	default:
+		l.report(ArgCount, ast.TokenOf(exp.Function), "%s expects %d to %d arguments, got %d", name, min, max, got)
+	}
+}
+
+// resolve marks the binding ident refers to as used. It returns false when
+// ident names a builtin or is undefined, reporting the latter with format.
+func (l *linter) resolve(ident *ast.Identifier, s *scope, format string) (*binding, bool) {
+	if b, ok := s.lookup(ident.Value); ok {
+		b.used = true
+		return b, true
+	}
+	if !evaluator.IsBuiltin(ident.Value) {
+		l.report(Undefined, ident.Token, format, ident.Value)
+	}
+	return nil, false
+}
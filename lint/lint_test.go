@@ -0,0 +1,128 @@
+package lint
+
+import (
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/parser"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 5; x;", []string{}},
+		{"let x = 5;", []string{"1:5: x is declared but never used [unused-binding]"}},
+		{"let _x = 5;", []string{}},
+		{"let f = fn(a, b) { a }; f(1, 2);", []string{"1:15: parameter b is never used [unused-param]"}},
+		{
+			"let x = 1; let f = fn() { let x = 2; x }; f() + x;",
+			[]string{"1:31: x shadows the binding declared at 1:5 [shadow]"},
+		},
+		{
+			"let len = fn(x) { x }; len(1);",
+			[]string{"1:5: len shadows the builtin function of the same name [shadow]"},
+		},
+		{
+			"let f = fn() { return 1; 2; }; f();",
+			[]string{"1:26: unreachable statement after return [unreachable]"},
+		},
+		{"foo(1);", []string{"1:1: call to undefined function foo [undefined]"}},
+		{"1 + y;", []string{"1:5: undefined identifier: y [undefined]"}},
+		{`len("a", "b");`, []string{"1:1: len expects 1 arguments, got 2 [arg-count]"}},
+		{
+			"let add = fn(a, b) { a + b }; add(1);",
+			[]string{"1:31: add expects 2 arguments, got 1 [arg-count]"},
+		},
+		{"fn(x) { x }(1, 2);", []string{"1:1: function literal expects 1 arguments, got 2 [arg-count]"}},
+		{
+			"let x = if (true) { 1 }; x;",
+			[]string{"1:9: if without else used as a value [if-value]"},
+		},
+		{"if (true) { 1 };", []string{}},
+		{"let x = if (true) { 1 } else { 2 }; x;", []string{}},
+		{
+			"let f = fn(n) { if (n < 1) { 0 } else { g(n) } }; let g = fn(n) { f(n - 1) }; f(3);",
+			[]string{},
+		},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5);", []string{}},
+		{"if (true) { let y = 1; }; y;", []string{}},
+		{"let f = fn(xs) { match (xs) { [x, ...tail] => x, _ => 0 } }; f([1]);", []string{"1:38: tail is declared but never used [unused-binding]"}},
+		{"let [a, b] = [1, 2]; a;", []string{"1:9: b is declared but never used [unused-binding]"}},
+		{"let f = fn({name, age = x}) { name }; f({});", []string{"1:19: parameter age is never used [unused-param]", "1:25: undefined identifier: x [undefined]"}},
+		{"let f = fn(a, b = 1) { a + b }; f(); f(1); f(1, 2, 3);", []string{"1:33: f expects 1 to 2 arguments, got 0 [arg-count]", "1:44: f expects 1 to 2 arguments, got 3 [arg-count]"}},
+		{"let f = fn(a, ...b) { [a, b] }; f(); f(1, 2, 3); f(...[]);", []string{"1:33: f expects at least 1 arguments, got 0 [arg-count]"}},
+		{"let f = fn(a = b) { a }; f();", []string{"1:16: undefined identifier: b [undefined]"}},
+		{"match (1) { x if x > 0 => x, _ => y };", []string{"1:35: undefined identifier: y [undefined]"}},
+		{"for ([k, v] in xs) { k };", []string{"1:10: v is declared but never used [unused-binding]", "1:16: undefined identifier: xs [undefined]"}},
+		{"let f = fn() { for (_ in range(3)) { yield 1 } }; collect(f()); take(f(), 1, 2);", []string{"1:65: take expects 2 arguments, got 3 [arg-count]"}},
+		{`slice([1]); format(); range(1, 2, 3, 4); puts(); zip([1]);`, []string{
+			"1:1: slice expects 2 to 3 arguments, got 1 [arg-count]",
+			"1:13: format expects at least 1 arguments, got 0 [arg-count]",
+			"1:23: range expects 1 to 3 arguments, got 4 [arg-count]",
+			"1:50: zip expects 2 arguments, got 1 [arg-count]",
+		}},
+	}
+
+	for _, tt := range tests {
+		findings := testLint(t, tt.input, Config{})
+
+		if len(findings) != len(tt.expected) {
+			t.Errorf("wrong number of findings for %q. want=%v, got=%v", tt.input, tt.expected, findings)
+			continue
+		}
+
+		for i, f := range findings {
+			if f.String() != tt.expected[i] {
+				t.Errorf("finding %d wrong for %q. want=%q, got=%q", i, tt.input, tt.expected[i], f.String())
+			}
+		}
+	}
+}
+
+func TestLintDisabledRules(t *testing.T) {
+	input := "let x = 5; foo();"
+
+	findings := testLint(t, input, Config{Disabled: map[string]bool{UnusedBinding: true}})
+	if len(findings) != 1 || findings[0].Rule != Undefined {
+		t.Fatalf("expected a single undefined finding. got=%v", findings)
+	}
+}
+
+func TestSourceSuppressions(t *testing.T) {
+	input := `// lint:ignore unused-binding
+let x = 5;
+let y = 6; // lint:ignore
+let z = 7;
+`
+
+	findings, errors := Source(input, Config{})
+	if len(errors) != 0 {
+		t.Fatalf("unexpected parser errors: %v", errors)
+	}
+
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding. got=%v", findings)
+	}
+
+	if findings[0].Line != 4 || findings[0].Rule != UnusedBinding {
+		t.Errorf("wrong finding. got=%s", findings[0])
+	}
+}
+
+func TestSourceParserErrors(t *testing.T) {
+	_, errors := Source("let = 5;", Config{})
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+}
+
+func testLint(t *testing.T, input string, config Config) []Finding {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+
+	return Lint(program, config)
+}
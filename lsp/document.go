@@ -0,0 +1,299 @@
+package lsp
+
+import (
+	"monkey_interpreter/ast"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// definition is a name bound by a let statement, a function parameter or a
+// pattern
+type definition struct {
+	ident *ast.Identifier
+	value ast.Expression // nil for parameters and patterns
+}
+
+// scope is the bindings of a program or function body along with the span of
+// source it covers, used to decide what is visible at a position
+type scope struct {
+	start, end token.Token
+	defs       []*definition
+	outer      *scope
+}
+
+// document is an open file and what is known about it from its last parse
+type document struct {
+	uri     string
+	text    string
+	lines   []string
+	program *ast.Program
+	errors  []parser.Error
+
+	idents []*ast.Identifier
+	refs   map[*ast.Identifier]*definition
+	scopes []*scope
+}
+
+func newDocument(uri, text string) *document {
+	p := parser.New(lexer.New(text))
+	d := &document{
+		uri:     uri,
+		text:    text,
+		lines:   strings.Split(text, "\n"),
+		program: p.ParseProgram(),
+		errors:  p.DetailedErrors(),
+		refs:    make(map[*ast.Identifier]*definition),
+	}
+
+	d.analyze(d.program.Statements, nil, nil, token.Token{Line: 1, Column: 1}, token.Token{Line: len(d.lines) + 1})
+
+	return d
+}
+
+// analyze collects the definitions made by stmts, which form a single scope,
+// then resolves every identifier used in them
+func (d *document) analyze(stmts []ast.Statement, params []*ast.Identifier, outer *scope, start, end token.Token) {
+	s := &scope{start: start, end: end, outer: outer}
+	d.scopes = append(d.scopes, s)
+
+	for _, param := range params {
+		def := &definition{ident: param}
+		s.defs = append(s.defs, def)
+		d.refs[param] = def
+		d.idents = append(d.idents, param)
+	}
+
+	var functions []*ast.FunctionLiteral
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FunctionLiteral:
+				functions = append(functions, n)
+				return false
+			case *ast.LetStatement:
+				if n.Name == nil {
+					break
+				}
+				def := &definition{ident: n.Name, value: n.Value}
+				s.defs = append(s.defs, def)
+				d.refs[n.Name] = def
+			case *ast.BindingPattern:
+				def := &definition{ident: n.Name}
+				s.defs = append(s.defs, def)
+				d.refs[n.Name] = def
+			}
+			return true
+		})
+	}
+
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.NamedArgument:
+			// the name is of a parameter of the function called, not a binding here
+			ast.Inspect(n.Value, visit)
+			return false
+		case *ast.Identifier:
+			d.idents = append(d.idents, n)
+			if _, declared := d.refs[n]; !declared {
+				if def := s.resolve(n); def != nil {
+					d.refs[n] = def
+				}
+			}
+		}
+		return true
+	}
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, visit)
+	}
+
+	for _, fn := range functions {
+		if fn.Body != nil {
+			params := []*ast.Identifier{}
+			for i, param := range fn.Parameters {
+				if pattern := fn.ParameterPattern(i); pattern != nil {
+					params = append(params, ast.PatternNames(pattern)...)
+				} else {
+					params = append(params, param)
+				}
+			}
+			d.analyze(fn.Body.Statements, params, s, fn.Token, fn.Body.Rbrace)
+		}
+	}
+}
+
+// resolve finds the definition ident refers to, preferring the closest one
+// made before it in the innermost scope that defines the name
+func (s *scope) resolve(ident *ast.Identifier) *definition {
+	for cur := s; cur != nil; cur = cur.outer {
+		var found *definition
+		for _, def := range cur.defs {
+			if def.ident.Value != ident.Value {
+				continue
+			}
+			if found == nil || before(def.ident.Token, ident.Token) {
+				found = def
+			}
+		}
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func before(a, b token.Token) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// identAt returns the identifier under a one-based line and column
+func (d *document) identAt(line, column int) *ast.Identifier {
+	for _, ident := range d.idents {
+		tok := ident.Token
+		if tok.Line == line && tok.Column <= column && column <= tok.Column+len(tok.Literal) {
+			return ident
+		}
+	}
+	return nil
+}
+
+// visible returns the definitions in scope at a one-based line and column, innermost first
+func (d *document) visible(line, column int) []*definition {
+	pos := token.Token{Line: line, Column: column}
+
+	var inner *scope
+	for _, s := range d.scopes {
+		if before(s.start, pos) && before(pos, s.end) {
+			inner = s
+		}
+	}
+
+	var defs []*definition
+	for s := inner; s != nil; s = s.outer {
+		defs = append(defs, s.defs...)
+	}
+	return defs
+}
+
+// position converts a one-based line and byte column to an LSP position
+func (d *document) position(line, column int) Position {
+	if line < 1 {
+		return Position{}
+	}
+	if line > len(d.lines) {
+		return Position{Line: line - 1}
+	}
+
+	text := d.lines[line-1]
+	if column-1 < len(text) {
+		text = text[:column-1]
+	}
+	return Position{Line: line - 1, Character: utf16Len(text)}
+}
+
+// tokenRange returns the range covered by tok
+func (d *document) tokenRange(tok token.Token) Range {
+	start := d.position(tok.Line, tok.Column)
+	end := d.position(tok.Line, tok.Column+len(tok.Literal))
+	return Range{Start: start, End: end}
+}
+
+// location converts an LSP position to a one-based line and byte column
+func (d *document) location(pos Position) (int, int) {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return pos.Line + 1, 1
+	}
+
+	text := d.lines[pos.Line]
+	units := 0
+	for i, r := range text {
+		if units >= pos.Character {
+			return pos.Line + 1, i + 1
+		}
+		units += utf16RuneLen(r)
+	}
+	return pos.Line + 1, len(text) + 1
+}
+
+// fullRange covers the whole document
+func (d *document) fullRange() Range {
+	last := len(d.lines) - 1
+	return Range{End: Position{Line: last, Character: utf16Len(d.lines[last])}}
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+// kind describes what a definition is bound to for hover text
+func (d *document) kind(def *definition) string {
+	if def.value == nil {
+		return "parameter"
+	}
+	return d.kindOf(def.value, 0)
+}
+
+// kindOf infers the kind of value exp evaluates to from its shape
+func (d *document) kindOf(exp ast.Expression, depth int) string {
+	const unknown = "unknown"
+	if depth > 16 {
+		return unknown
+	}
+
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return "integer"
+	case *ast.FloatLiteral:
+		return "float"
+	case *ast.StringLiteral:
+		return "string"
+	case *ast.Boolean:
+		return "boolean"
+	case *ast.ArrayLiteral:
+		return "array"
+	case *ast.HashLiteral:
+		return "hash"
+	case *ast.FunctionLiteral:
+		params := []string{}
+		for _, p := range exp.Parameters {
+			params = append(params, p.Value)
+		}
+		return "fn(" + strings.Join(params, ", ") + ")"
+	case *ast.PrefixExpression:
+		if exp.Operator == "!" {
+			return "boolean"
+		}
+		return d.kindOf(exp.Right, depth+1)
+	case *ast.InfixExpression:
+		switch exp.Operator {
+		case "==", "!=", "<", ">", "in":
+			return "boolean"
+		}
+		left, right := d.kindOf(exp.Left, depth+1), d.kindOf(exp.Right, depth+1)
+		if left == right {
+			return left
+		}
+	case *ast.Identifier:
+		if def, ok := d.refs[exp]; ok && def.value != nil {
+			return d.kindOf(def.value, depth+1)
+		}
+	}
+
+	return unknown
+}
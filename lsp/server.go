@@ -0,0 +1,328 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/format"
+	"monkey_interpreter/lint"
+	"monkey_interpreter/token"
+	"monkey_interpreter/wire"
+	"sort"
+	"strings"
+)
+
+// keywords offered as completions alongside identifiers
+var keywords = []string{"fn", "let", "true", "false", "if", "else", "return", "in", "match", "for", "yield"}
+
+// Server speaks the Language Server Protocol over a pair of streams
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool
+}
+
+// NewServer returns a server reading requests from in and writing replies to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the client sends exit or closes the input
+func (s *Server) Serve() error {
+	for {
+		body, err := wire.Read(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.replyError(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		if err := s.handle(&req); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req *request) error {
+	if s.shutdown && req.ID != nil {
+		return s.replyError(req.ID, codeInvalidRequest, "server is shutting down")
+	}
+
+	var result interface{}
+	var err error
+
+	switch req.Method {
+	case "initialize":
+		result = map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":           1, // full document sync
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"documentSymbolProvider":     true,
+				"documentFormattingProvider": true,
+				"completionProvider":         map[string]interface{}{},
+			},
+			"serverInfo": map[string]string{"name": "monkey-lsp"},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			err = s.open(params.TextDocument.URI, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			changes := params.ContentChanges
+			err = s.open(params.TextDocument.URI, changes[len(changes)-1].Text)
+		}
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			delete(s.docs, params.TextDocument.URI)
+		}
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.hover(params)
+		}
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.definition(params)
+		}
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.symbols(params)
+		}
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.completion(params)
+		}
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.formatting(params)
+		}
+	default:
+		if req.ID != nil {
+			return s.replyError(req.ID, codeMethodNotFound, "method not found: "+req.Method)
+		}
+		return nil
+	}
+
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		return s.replyError(req.ID, codeInvalidParams, err.Error())
+	}
+	return wire.Write(s.out, response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+func (s *Server) replyError(id *json.RawMessage, code int, msg string) error {
+	return wire.Write(s.out, errorResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &responseError{Code: code, Message: msg},
+	})
+}
+
+// open parses a new version of a document and publishes its diagnostics
+func (s *Server) open(uri, text string) error {
+	d := newDocument(uri, text)
+	s.docs[uri] = d
+
+	diagnostics := []Diagnostic{}
+	for _, err := range d.errors {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.tokenRange(err.Token),
+			Severity: SeverityError,
+			Source:   "monkey",
+			Message:  err.Message,
+		})
+	}
+	if len(d.errors) == 0 {
+		for _, f := range lint.Lint(d.program, lint.Config{}) {
+			tok := token.Token{Line: f.Line, Column: f.Column}
+			if ident := d.identAt(f.Line, f.Column); ident != nil {
+				tok = ident.Token
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    d.tokenRange(tok),
+				Severity: SeverityWarning,
+				Code:     f.Rule,
+				Source:   "monkey-lint",
+				Message:  f.Message,
+			})
+		}
+	}
+
+	return wire.Write(s.out, notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics},
+	})
+}
+
+// lookup returns the document and identifier at the requested position
+func (s *Server) lookup(params TextDocumentPositionParams) (*document, *ast.Identifier) {
+	d, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	line, column := d.location(params.Position)
+	return d, d.identAt(line, column)
+}
+
+func (s *Server) hover(params TextDocumentPositionParams) *Hover {
+	d, ident := s.lookup(params)
+	if ident == nil {
+		return nil
+	}
+
+	var text string
+	if def, ok := d.refs[ident]; ok {
+		text = ident.Value + ": " + d.kind(def)
+	} else if evaluator.IsBuiltinModule(ident.Value) {
+		text = ident.Value + ": builtin module"
+	} else if evaluator.IsBuiltin(ident.Value) {
+		text = ident.Value + ": builtin function"
+	} else {
+		return nil
+	}
+
+	r := d.tokenRange(ident.Token)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```monkey\n" + text + "\n```"},
+		Range:    &r,
+	}
+}
+
+func (s *Server) definition(params TextDocumentPositionParams) *Location {
+	d, ident := s.lookup(params)
+	if ident == nil {
+		return nil
+	}
+
+	def, ok := d.refs[ident]
+	if !ok {
+		return nil
+	}
+	return &Location{URI: d.uri, Range: d.tokenRange(def.ident.Token)}
+}
+
+func (s *Server) symbols(params DocumentSymbolParams) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+
+	d, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return symbols
+	}
+
+	for _, stmt := range d.program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || let.Name == nil {
+			continue
+		}
+
+		symbol := DocumentSymbol{
+			Name:           let.Name.Value,
+			Kind:           SymbolVariable,
+			SelectionRange: d.tokenRange(let.Name.Token),
+		}
+		end := d.position(let.Token.Line, len(d.lines[let.Token.Line-1])+1)
+		if fn, ok := let.Value.(*ast.FunctionLiteral); ok && fn.Body != nil {
+			symbol.Kind = SymbolFunction
+			symbol.Detail = d.kindOf(fn, 0)
+			end = d.tokenRange(fn.Body.Rbrace).End
+		}
+		symbol.Range = Range{Start: d.position(let.Token.Line, let.Token.Column), End: end}
+
+		symbols = append(symbols, symbol)
+	}
+
+	return symbols
+}
+
+func (s *Server) completion(params TextDocumentPositionParams) []CompletionItem {
+	items := []CompletionItem{}
+
+	d, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return items
+	}
+
+	seen := make(map[string]bool)
+	line, column := d.location(params.Position)
+	for _, def := range d.visible(line, column) {
+		if seen[def.ident.Value] {
+			continue
+		}
+		seen[def.ident.Value] = true
+
+		item := CompletionItem{Label: def.ident.Value, Kind: CompletionVariable, Detail: d.kind(def)}
+		if _, ok := def.value.(*ast.FunctionLiteral); ok {
+			item.Kind = CompletionFunction
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+
+	for _, name := range evaluator.BuiltinNames() {
+		if seen[name] {
+			continue
+		}
+		if evaluator.IsBuiltinModule(name) {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionModule, Detail: "builtin module"})
+		} else {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionFunction, Detail: "builtin function"})
+		}
+	}
+	for _, kw := range keywords {
+		items = append(items, CompletionItem{Label: kw, Kind: CompletionKeyword})
+	}
+
+	return items
+}
+
+func (s *Server) formatting(params DocumentFormattingParams) []TextEdit {
+	d, ok := s.docs[params.TextDocument.URI]
+	if !ok || len(d.errors) != 0 {
+		return nil
+	}
+
+	config := format.Config{Indent: "\t"}
+	if params.Options.InsertSpaces {
+		config.Indent = strings.Repeat(" ", params.Options.TabSize)
+	}
+
+	formatted, errors := format.Source(d.text, config)
+	if len(errors) != 0 || formatted == d.text {
+		return []TextEdit{}
+	}
+	return []TextEdit{{Range: d.fullRange(), NewText: formatted}}
+}
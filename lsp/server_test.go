@@ -0,0 +1,220 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"monkey_interpreter/wire"
+	"testing"
+)
+
+const testURI = "file:///test.mk"
+
+const testSource = `let x = 5;
+let add = fn(a, b) {
+  a + b
+};
+add(x, 1);
+let y = "hi" + "!";
+let 5`
+
+// client scripts a session with the server and collects its replies by request ID
+type client struct {
+	t      *testing.T
+	input  bytes.Buffer
+	nextID int
+}
+
+func (c *client) send(method string, params interface{}) int {
+	c.nextID++
+	if err := wire.Write(&c.input, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID,
+		"method":  method,
+		"params":  params,
+	}); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.nextID
+}
+
+func (c *client) notify(method string, params interface{}) {
+	if err := wire.Write(&c.input, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+type reply struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// run serves the scripted session and returns the replies in order
+func (c *client) run() []reply {
+	c.notify("exit", nil)
+
+	var output bytes.Buffer
+	if err := NewServer(&c.input, &output).Serve(); err != nil {
+		c.t.Fatalf("Serve returned error: %v", err)
+	}
+
+	var replies []reply
+	r := bufio.NewReader(&output)
+	for {
+		body, err := wire.Read(r)
+		if err != nil {
+			break
+		}
+		var msg reply
+		if err := json.Unmarshal(body, &msg); err != nil {
+			c.t.Fatalf("invalid reply %q: %v", body, err)
+		}
+		replies = append(replies, msg)
+	}
+	return replies
+}
+
+func result(t *testing.T, replies []reply, id int, v interface{}) {
+	for _, r := range replies {
+		if r.ID != nil && *r.ID == id {
+			if r.Error != nil {
+				t.Fatalf("request %d failed: %s", id, r.Error.Message)
+			}
+			if err := json.Unmarshal(r.Result, v); err != nil {
+				t.Fatalf("request %d returned invalid result %s: %v", id, r.Result, err)
+			}
+			return
+		}
+	}
+	t.Fatalf("no reply to request %d", id)
+}
+
+func position(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": testURI},
+		"position":     Position{Line: line, Character: character},
+	}
+}
+
+func TestSession(t *testing.T) {
+	c := &client{t: t}
+	initialize := c.send("initialize", map[string]interface{}{})
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": TextDocumentItem{URI: testURI, LanguageID: "monkey", Text: testSource},
+	})
+	hoverX := c.send("textDocument/hover", position(4, 5))
+	hoverY := c.send("textDocument/hover", position(5, 4))
+	hoverParam := c.send("textDocument/hover", position(2, 14))
+	definition := c.send("textDocument/definition", position(2, 2))
+	symbols := c.send("textDocument/documentSymbol", map[string]interface{}{
+		"textDocument": map[string]string{"uri": testURI},
+	})
+	completion := c.send("textDocument/completion", position(2, 2))
+	unknown := c.send("monkey/unknown", nil)
+	shutdown := c.send("shutdown", nil)
+
+	replies := c.run()
+
+	var init struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	result(t, replies, initialize, &init)
+	if init.Capabilities["hoverProvider"] != true {
+		t.Errorf("hover not advertised. got=%v", init.Capabilities)
+	}
+
+	var diagnostics PublishDiagnosticsParams
+	for _, r := range replies {
+		if r.Method == "textDocument/publishDiagnostics" {
+			if err := json.Unmarshal(r.Params, &diagnostics); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if len(diagnostics.Diagnostics) == 0 {
+		t.Fatalf("expected diagnostics for the trailing parse error")
+	}
+	if diagnostics.Diagnostics[0].Severity != SeverityError || diagnostics.Diagnostics[0].Range.Start.Line != 6 {
+		t.Errorf("wrong diagnostic. got=%+v", diagnostics.Diagnostics[0])
+	}
+
+	hovers := []struct {
+		id       int
+		expected string
+	}{
+		{hoverX, "```monkey\nx: integer\n```"},
+		{hoverY, "```monkey\ny: string\n```"},
+		{hoverParam, "```monkey\nb: parameter\n```"},
+	}
+	for _, h := range hovers {
+		var hover Hover
+		result(t, replies, h.id, &hover)
+		if hover.Contents.Value != h.expected {
+			t.Errorf("wrong hover. want=%q, got=%q", h.expected, hover.Contents.Value)
+		}
+	}
+
+	var loc Location
+	result(t, replies, definition, &loc)
+	if loc.Range.Start != (Position{Line: 1, Character: 13}) {
+		t.Errorf("definition of a points at wrong place. got=%+v", loc.Range)
+	}
+
+	var syms []DocumentSymbol
+	result(t, replies, symbols, &syms)
+	if len(syms) != 3 || syms[1].Name != "add" || syms[1].Kind != SymbolFunction || syms[1].Range.End.Line != 3 {
+		t.Errorf("wrong document symbols. got=%+v", syms)
+	}
+
+	var items []CompletionItem
+	result(t, replies, completion, &items)
+	labels := make(map[string]bool)
+	for _, item := range items {
+		labels[item.Label] = true
+	}
+	for _, want := range []string{"a", "b", "x", "add", "len", "fn"} {
+		if !labels[want] {
+			t.Errorf("completion missing %q. got=%v", want, items)
+		}
+	}
+
+	for _, r := range replies {
+		if r.ID != nil && *r.ID == unknown && (r.Error == nil || r.Error.Code != codeMethodNotFound) {
+			t.Errorf("unknown method should fail with method not found. got=%+v", r)
+		}
+	}
+
+	var null interface{}
+	result(t, replies, shutdown, &null)
+}
+
+func TestFormatting(t *testing.T) {
+	c := &client{t: t}
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": TextDocumentItem{URI: testURI, Text: "let f=fn(x){x*2};\nf(2)"},
+	})
+	formatting := c.send("textDocument/formatting", map[string]interface{}{
+		"textDocument": map[string]string{"uri": testURI},
+		"options":      FormattingOptions{TabSize: 2, InsertSpaces: true},
+	})
+
+	var edits []TextEdit
+	result(t, c.run(), formatting, &edits)
+
+	if len(edits) != 1 {
+		t.Fatalf("expected a single edit. got=%+v", edits)
+	}
+	if edits[0].NewText != "let f = fn(x) {\n  x * 2\n};\nf(2);\n" {
+		t.Errorf("wrong formatted text. got=%q", edits[0].NewText)
+	}
+	if edits[0].Range.End != (Position{Line: 1, Character: 4}) {
+		t.Errorf("edit does not cover the document. got=%+v", edits[0].Range)
+	}
+}
@@ -1,18 +1,63 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"monkey_interpreter/dap"
+	"monkey_interpreter/lsp"
 	"monkey_interpreter/repl"
 	"os"
 	"os/user"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "run":
+			os.Exit(runFile(os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "lsp":
+			if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		case "dap":
+			if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		case "debug":
+			flags := flag.NewFlagSet("debug", flag.ExitOnError)
+			policy := policyFlags(flags)
+			flags.Parse(os.Args[2:])
+			if flags.NArg() != 1 {
+				fmt.Fprintln(os.Stderr, "usage: monkey debug [flags] file")
+				os.Exit(2)
+			}
+			source, err := os.ReadFile(flags.Arg(0))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			repl.Debug(os.Stdin, os.Stdout, string(source), policy())
+			return
+		}
+	}
+
+	flags := flag.NewFlagSet("monkey", flag.ExitOnError)
+	policy := policyFlags(flags)
+	flags.Parse(os.Args[1:])
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
 	}
 	fmt.Printf("Hello %s! This is the Monkey programming language!\n", user.Username)
 	fmt.Printf("Feel free to type in commands\n")
-	repl.Start(os.Stdin, os.Stdout)
+	repl.Start(os.Stdin, os.Stdout, policy())
 }
@@ -1,5 +1,7 @@
 package object
 
+import "sort"
+
 // NewEnclosedEnvironment function
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
@@ -13,14 +15,26 @@ func NewEnvironment() *Environment {
 	return &Environment{store: s, outer: nil}
 }
 
-// Environment struct
+// NewLocalEnvironment returns an environment for a function call enclosed by
+// outer, with a slot for each of names
+func NewLocalEnvironment(outer *Environment, names []string) *Environment {
+	return &Environment{slots: make([]Object, len(names)), names: names, outer: outer}
+}
+
+// Environment struct. Bindings of resolved identifiers live in slots, which
+// are empty until set; everything else is in store by name.
 type Environment struct {
 	store map[string]Object
+	slots []Object
+	names []string // the name of each slot
 	outer *Environment
 }
 
 // Get ter for environment
 func (e *Environment) Get(name string) (Object, bool) {
+	if i := e.slot(name); i >= 0 && e.slots[i] != nil {
+		return e.slots[i], true
+	}
 	obj, ok := e.store[name]
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
@@ -30,6 +44,82 @@ func (e *Environment) Get(name string) (Object, bool) {
 
 // Set ter for environment
 func (e *Environment) Set(name string, val Object) Object {
+	if i := e.slot(name); i >= 0 {
+		e.slots[i] = val
+		return val
+	}
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
 	e.store[name] = val
 	return val
 }
+
+// slot returns the index of the slot for name, or -1
+func (e *Environment) slot(name string) int {
+	for i, n := range e.names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Up returns the environment depth levels out, stopping at the outermost
+func (e *Environment) Up(depth int) *Environment {
+	for ; depth > 0 && e.outer != nil; depth-- {
+		e = e.outer
+	}
+	return e
+}
+
+// GetSlot returns the value in slot index of the environment depth levels out,
+// reporting whether it has been set
+func (e *Environment) GetSlot(depth, index int) (Object, bool) {
+	e = e.Up(depth)
+	if index >= len(e.slots) || e.slots[index] == nil {
+		return nil, false
+	}
+	return e.slots[index], true
+}
+
+// SetSlot sets slot index
+func (e *Environment) SetSlot(index int, val Object) Object {
+	e.slots[index] = val
+	return val
+}
+
+// Outer returns the enclosing environment, or nil for the global one
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Names returns the names bound directly in this environment in sorted order
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store)+len(e.slots))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	for i, name := range e.names {
+		if e.slots[i] != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Assign rebinds name in the innermost environment that already defines it
+func (e *Environment) Assign(name string, val Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if i := env.slot(name); i >= 0 && env.slots[i] != nil {
+			env.slots[i] = val
+			return true
+		}
+		if _, ok := env.store[name]; ok {
+			env.store[name] = val
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package object
+
+import "math/bits"
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+// hamtNode is a node of a hash array mapped trie keyed by HashKey. Each level
+// consumes five bits of the key's hash, and bitmap records which of the 32
+// possible slots are present, so slots holds only those. Keys whose hashes are
+// equal in all 64 bits end up together in a node below the last level, where
+// slots is searched in order. Updates copy the path from the root and share
+// everything else, so every version of a trie stays valid.
+type hamtNode struct {
+	bitmap uint32
+	slots  []hamtSlot
+}
+
+// hamtSlot holds a pair, or a subtree when node is set. seq orders pairs by
+// when their key was first added.
+type hamtSlot struct {
+	key  HashKey
+	pair HashPair
+	seq  int
+	node *hamtNode
+}
+
+var emptyHamt = &hamtNode{}
+
+// position returns the bit for key at shift and the index its slot would have
+func (n *hamtNode) position(key HashKey, shift uint) (uint32, int) {
+	bit := uint32(1) << ((key.Value >> shift) & hamtMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// collisions reports whether a node at shift holds keys whose hashes are equal
+func collisions(shift uint) bool {
+	return shift >= 64
+}
+
+func (n *hamtNode) get(key HashKey, shift uint) (hamtSlot, bool) {
+	for {
+		if collisions(shift) {
+			for _, slot := range n.slots {
+				if slot.key == key {
+					return slot, true
+				}
+			}
+			return hamtSlot{}, false
+		}
+
+		bit, i := n.position(key, shift)
+		if n.bitmap&bit == 0 {
+			return hamtSlot{}, false
+		}
+		slot := n.slots[i]
+		if slot.node == nil {
+			return slot, slot.key == key
+		}
+		n, shift = slot.node, shift+hamtBits
+	}
+}
+
+// set returns n with s stored under its key, reporting whether the key is new.
+// A replaced pair keeps the seq of the pair it replaces.
+func (n *hamtNode) set(s hamtSlot, shift uint) (*hamtNode, bool) {
+	if collisions(shift) {
+		for i, slot := range n.slots {
+			if slot.key == s.key {
+				s.seq = slot.seq
+				return n.replace(i, s), false
+			}
+		}
+		return &hamtNode{slots: append(n.slots[:len(n.slots):len(n.slots)], s)}, true
+	}
+
+	bit, i := n.position(s.key, shift)
+	if n.bitmap&bit == 0 {
+		slots := make([]hamtSlot, len(n.slots)+1)
+		copy(slots, n.slots[:i])
+		slots[i] = s
+		copy(slots[i+1:], n.slots[i:])
+		return &hamtNode{bitmap: n.bitmap | bit, slots: slots}, true
+	}
+
+	existing := n.slots[i]
+	switch {
+	case existing.node != nil:
+		child, added := existing.node.set(s, shift+hamtBits)
+		return n.replace(i, hamtSlot{node: child}), added
+	case existing.key == s.key:
+		s.seq = existing.seq
+		return n.replace(i, s), false
+	default:
+		child, _ := emptyHamt.set(existing, shift+hamtBits)
+		child, _ = child.set(s, shift+hamtBits)
+		return n.replace(i, hamtSlot{node: child}), true
+	}
+}
+
+// delete returns n without key, reporting whether it was there
+func (n *hamtNode) delete(key HashKey, shift uint) (*hamtNode, bool) {
+	if collisions(shift) {
+		for i, slot := range n.slots {
+			if slot.key == key {
+				return n.remove(i, 0), true
+			}
+		}
+		return n, false
+	}
+
+	bit, i := n.position(key, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	existing := n.slots[i]
+	if existing.node == nil {
+		if existing.key != key {
+			return n, false
+		}
+		return n.remove(i, bit), true
+	}
+
+	child, removed := existing.node.delete(key, shift+hamtBits)
+	if !removed {
+		return n, false
+	}
+	if len(child.slots) == 1 && child.slots[0].node == nil {
+		// a subtree left with a single pair is replaced by the pair
+		return n.replace(i, child.slots[0]), true
+	}
+	return n.replace(i, hamtSlot{node: child}), true
+}
+
+// replace returns a copy of n with slot i replaced by s
+func (n *hamtNode) replace(i int, s hamtSlot) *hamtNode {
+	slots := make([]hamtSlot, len(n.slots))
+	copy(slots, n.slots)
+	slots[i] = s
+	return &hamtNode{bitmap: n.bitmap, slots: slots}
+}
+
+// remove returns a copy of n without slot i, which is at bit
+func (n *hamtNode) remove(i int, bit uint32) *hamtNode {
+	slots := make([]hamtSlot, len(n.slots)-1)
+	copy(slots, n.slots[:i])
+	copy(slots[i:], n.slots[i+1:])
+	return &hamtNode{bitmap: n.bitmap &^ bit, slots: slots}
+}
+
+// each calls f for every pair under n in no particular order
+func (n *hamtNode) each(f func(hamtSlot)) {
+	for _, slot := range n.slots {
+		if slot.node != nil {
+			slot.node.each(f)
+		} else {
+			f(slot)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package object
+
+import "io"
+
+// Capability is a kind of access to the host system a builtin may need
+type Capability string
+
+const (
+	// READ reads files under a path
+	READ Capability = "read"
+	// WRITE creates and modifies files under a path
+	WRITE Capability = "write"
+	// ENV reads an environment variable
+	ENV Capability = "env"
+	// CLOCK reads the current time
+	CLOCK Capability = "clock"
+	// RANDOM draws random numbers
+	RANDOM Capability = "random"
+	// OUTPUT prints to the interpreter's output
+	OUTPUT Capability = "output"
+)
+
+// FileSystem is the filesystem seen by the file builtins. Errors should be
+// *fs.PathError values, as returned by the os package.
+type FileSystem interface {
+	// ReadFile returns the contents of the file name
+	ReadFile(name string) ([]byte, error)
+	// WriteFile creates or truncates the file name and writes data, or appends
+	// data to it if append is set
+	WriteFile(name string, data []byte, append bool) error
+	// ReadDir returns the names of the entries of the directory name in order
+	ReadDir(name string) ([]string, error)
+	// Exists reports whether a file or directory called name exists
+	Exists(name string) bool
+	// MkdirAll creates the directory name along with any missing parents
+	MkdirAll(name string) error
+	// Remove removes the file or empty directory name
+	Remove(name string) error
+}
+
+// Host is the interpreter as seen by the builtins it runs
+type Host interface {
+	// Check returns an error unless the run may use capability on target, which is
+	// a path for READ and WRITE, a variable name for ENV and empty otherwise
+	Check(capability Capability, target string) *Error
+	// Output returns the writer printing builtins write to
+	Output() io.Writer
+	// Files returns the filesystem file builtins read and write through
+	Files() FileSystem
+	// Call applies fn, a function or builtin, to args and returns the result
+	Call(fn Object, args ...Object) Object
+	// Import returns the module at path, evaluating it on first use, or an error
+	Import(path string) Object
+	// Step counts a unit of work a builtin does without calling back into the
+	// program, such as producing an element of an iterator, against the run's
+	// limits, returning an error once the run has to stop
+	Step() *Error
+	// Allocate returns an error once the run has to stop, as it does when n
+	// more array elements, hash pairs or string bytes would not fit within the
+	// run's limits. Builtins call it before building a result that may be
+	// large; the result is counted against the limits once they return it.
+	Allocate(n int) *Error
+}
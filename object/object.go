@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
 	"monkey_interpreter/ast"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +17,7 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -22,6 +27,8 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	MODULE_OBJ       = "MODULE"
+	ITERATOR_OBJ     = "ITERATOR"
 )
 
 // Object interface
@@ -30,17 +37,58 @@ type Object interface {
 	Inspect() string
 }
 
-// Integer struct
+// Integer struct. Integers outside the range of int64 are held in Big, in
+// which case Value is zero; integers within it never use Big.
 type Integer struct {
 	Value int64
+	Big   *big.Int
+}
+
+// NewBigInteger returns an Integer holding b, which must not be modified afterwards
+func NewBigInteger(b *big.Int) *Integer {
+	if b.IsInt64() {
+		return &Integer{Value: b.Int64()}
+	}
+	return &Integer{Big: b}
+}
+
+// BigValue returns the value as a big.Int, which callers must not modify
+func (i *Integer) BigValue() *big.Int {
+	if i.Big != nil {
+		return i.Big
+	}
+	return big.NewInt(i.Value)
 }
 
 // Inspect method for integer type
-func (i *Integer) Inspect() string { return fmt.Sprintf("%d", i.Value) }
+func (i *Integer) Inspect() string {
+	if i.Big != nil {
+		return i.Big.String()
+	}
+	return fmt.Sprintf("%d", i.Value)
+}
 
 // Type method returns integer ObjectType
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
+// Float struct
+type Float struct {
+	Value float64
+}
+
+// Type method returns float ObjectType
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
+// Inspect method for float type, which always shows a fraction or exponent
+// so floats can be told apart from integers
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
 // Boolean struct
 type Boolean struct {
 	Value bool
@@ -75,6 +123,9 @@ func (rv *ReturnValue) Inspect() string { return rv.Value.Inspect() }
 // Error struct
 type Error struct {
 	Message string
+	// Code classifies errors raised by the interpreter rather than by an operation of
+	// the program, such as exceeding a limit or a denied capability, and is empty otherwise
+	Code string
 }
 
 // Type method returns Error ObjectType
@@ -86,8 +137,30 @@ func (e *Error) Inspect() string { return "ERROR: " + e.Message }
 // Function struct
 type Function struct {
 	Parameters []*ast.Identifier
+	Patterns   []ast.Pattern    // the patterns parameters destructure with, if any
+	Defaults   []ast.Expression // the defaults of parameters, if any
+	Variadic   bool             // whether the last parameter collects the arguments left over
+	Generator  bool             // whether calls return an iterator over what the body yields
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Locals     []string // the slots of the environment of each call
+	File       string   // the source the function was defined in, which relative imports in it start from
+}
+
+// Pattern returns the pattern parameter i destructures with, or nil
+func (f *Function) Pattern(i int) ast.Pattern {
+	if i < len(f.Patterns) {
+		return f.Patterns[i]
+	}
+	return nil
+}
+
+// Default returns the default of parameter i, or nil
+func (f *Function) Default(i int) ast.Expression {
+	if i < len(f.Defaults) {
+		return f.Defaults[i]
+	}
+	return nil
 }
 
 // Type returns function ObjType
@@ -98,8 +171,15 @@ func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
+	for i, p := range f.Parameters {
+		param := p.String()
+		if f.Variadic && i == len(f.Parameters)-1 {
+			param = "..." + param
+		}
+		if d := f.Default(i); d != nil {
+			param += " = " + d.String()
+		}
+		params = append(params, param)
 	}
 
 	out.WriteString("fn")
@@ -126,6 +206,13 @@ func (s *String) Inspect() string { return s.Value }
 // Builtin struct
 type Builtin struct {
 	Fn BuiltinFunction
+	// MinArgs and MaxArgs bound the number of arguments Fn is called with,
+	// MaxArgs being -1 when there is no limit. The evaluator checks them.
+	MinArgs, MaxArgs int
+	// Reuses is set when Fn returns an element of an argument or what a
+	// function it was given returned rather than an object of its own, so the
+	// result is not counted against the element limit again
+	Reuses bool
 }
 
 // Type returns Builtin Object Type
@@ -134,12 +221,60 @@ func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 // Inspect method for Builtin Type
 func (b *Builtin) Inspect() string { return "builtin function" }
 
-// BuiltinFunction function
-type BuiltinFunction func(args ...Object) Object
+// BuiltinFunction function, called with the Host running it
+type BuiltinFunction func(host Host, args ...Object) Object
 
-// Array struct
+// Array struct. Arrays are immutable: the elements are a window onto a
+// persistent vector, so pushing, taking the rest and slicing share structure
+// with the original instead of copying it.
 type Array struct {
-	Elements []Object
+	vec        *vector
+	start, end int
+}
+
+// NewArray returns an Array holding elements
+func NewArray(elements []Object) *Array {
+	vec := emptyVector
+	for _, el := range elements {
+		vec = vec.push(el)
+	}
+	return &Array{vec: vec, end: len(elements)}
+}
+
+// Len returns the number of elements
+func (ao *Array) Len() int { return ao.end - ao.start }
+
+// Get returns element i, which must be between 0 and Len() - 1
+func (ao *Array) Get(i int) Object { return ao.vec.get(ao.start + i) }
+
+// Elements returns a new slice holding the elements
+func (ao *Array) Elements() []Object {
+	elements := make([]Object, ao.Len())
+	for i := range elements {
+		elements[i] = ao.Get(i)
+	}
+	return elements
+}
+
+// Push returns an Array with the elements of ao followed by el
+func (ao *Array) Push(el Object) *Array {
+	vec := ao.vec
+	switch {
+	case vec == nil:
+		vec = emptyVector.push(el)
+	case ao.end == vec.count:
+		vec = vec.push(el)
+	default:
+		// elements past the window are unused, so the next one can be overwritten
+		vec = vec.set(ao.end, el)
+	}
+	return &Array{vec: vec, start: ao.start, end: ao.end + 1}
+}
+
+// Slice returns an Array with elements from up to but not including to, which
+// must satisfy 0 <= from <= to <= Len()
+func (ao *Array) Slice(from, to int) *Array {
+	return &Array{vec: ao.vec, start: ao.start + from, end: ao.start + to}
 }
 
 // Type returns Array object type
@@ -150,7 +285,7 @@ func (ao *Array) Inspect() string {
 	var out bytes.Buffer
 
 	elements := []string{}
-	for _, e := range ao.Elements {
+	for _, e := range ao.Elements() {
 		elements = append(elements, e.Inspect())
 	}
 
@@ -182,9 +317,27 @@ func (b *Boolean) HashKey() HashKey {
 
 // HashKey function Ints
 func (i *Integer) HashKey() HashKey {
+	if i.Big != nil {
+		h := fnv.New64a()
+		h.Write([]byte(i.Big.String()))
+		return HashKey{Type: i.Type(), Value: h.Sum64()}
+	}
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// HashKey function Floats. Whole numbers have the key of the integer they
+// equal, so 1.0 finds what was set with 1.
+func (f *Float) HashKey() HashKey {
+	switch {
+	case f.Value != math.Trunc(f.Value) || math.IsInf(f.Value, 0):
+		return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+	case f.Value >= math.MinInt64 && f.Value < math.MaxInt64:
+		return (&Integer{Value: int64(f.Value)}).HashKey()
+	}
+	n, _ := big.NewFloat(f.Value).Int(nil)
+	return NewBigInteger(n).HashKey()
+}
+
 // HashKey function Strings
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
@@ -199,9 +352,74 @@ type HashPair struct {
 	Value Object
 }
 
-// Hash struct
+// Hash struct. The pairs live in a hash array mapped trie that versions share,
+// so Copy is cheap and Set and Delete on a copy leave the original alone. Pairs
+// are kept in the order their keys were first set.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	root *hamtNode
+	size int
+	seq  int // the seq of the next new key
+}
+
+// NewHash returns an empty Hash
+func NewHash() *Hash {
+	return &Hash{root: emptyHamt}
+}
+
+func (h *Hash) trie() *hamtNode {
+	if h.root == nil {
+		return emptyHamt
+	}
+	return h.root
+}
+
+// Get returns the pair with key
+func (h *Hash) Get(key HashKey) (HashPair, bool) {
+	slot, ok := h.trie().get(key, 0)
+	return slot.pair, ok
+}
+
+// Len returns the number of pairs
+func (h *Hash) Len() int { return h.size }
+
+// Set adds a pair, or replaces the pair with the same key in its original position
+func (h *Hash) Set(key HashKey, pair HashPair) {
+	root, added := h.trie().set(hamtSlot{key: key, pair: pair, seq: h.seq}, 0)
+	h.root = root
+	if added {
+		h.size++
+		h.seq++
+	}
+}
+
+// Delete removes the pair with key, if any
+func (h *Hash) Delete(key HashKey) {
+	root, removed := h.trie().delete(key, 0)
+	h.root = root
+	if removed {
+		h.size--
+	}
+}
+
+// Items returns the pairs in insertion order
+func (h *Hash) Items() []HashPair {
+	slots := make([]hamtSlot, 0, h.size)
+	h.trie().each(func(slot hamtSlot) {
+		slots = append(slots, slot)
+	})
+	sort.Slice(slots, func(i, j int) bool { return slots[i].seq < slots[j].seq })
+
+	items := make([]HashPair, len(slots))
+	for i, slot := range slots {
+		items[i] = slot.pair
+	}
+	return items
+}
+
+// Copy returns a Hash with the same pairs in the same order
+func (h *Hash) Copy() *Hash {
+	copied := *h
+	return &copied
 }
 
 // Type function returns Hash ObjectType
@@ -212,7 +430,7 @@ func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.Items() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
@@ -223,6 +441,28 @@ func (h *Hash) Inspect() string {
 	return out.String()
 }
 
+// Module is an imported file, whose top-level bindings live in Env
+type Module struct {
+	Path string
+	Env  *Environment
+}
+
+// Type returns Module ObjectType
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+
+// Inspect method for Module objects
+func (m *Module) Inspect() string { return "module(" + m.Path + ")" }
+
+// Iterator produces a sequence of objects one at a time, for for-in and the
+// builtins taking lists. Iterators are used up as they go, so a second loop
+// over one carries on where the first left off.
+type Iterator interface {
+	Object
+	// Next returns the next object and true, or false once there are none
+	// left. An Error ends the sequence, reporting why it could not go on.
+	Next() (Object, bool)
+}
+
 // Hashable interface
 type Hashable interface {
 	HashKey() HashKey
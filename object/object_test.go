@@ -1,6 +1,10 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"math/big"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -20,3 +24,239 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestHashOrder(t *testing.T) {
+	hash := NewHash()
+	for _, key := range []string{"c", "a", "b", "a"} {
+		k := &String{Value: key}
+		hash.Set(k.HashKey(), HashPair{Key: k, Value: &Integer{Value: int64(hash.Len())}})
+	}
+	hash.Delete((&String{Value: "c"}).HashKey())
+
+	copied := hash.Copy()
+	copied.Set((&String{Value: "d"}).HashKey(), HashPair{Key: &String{Value: "d"}, Value: &Integer{Value: 9}})
+
+	if hash.Inspect() != "{a: 3, b: 2}" {
+		t.Errorf("wrong order after set and delete. got=%s", hash.Inspect())
+	}
+	if copied.Inspect() != "{a: 3, b: 2, d: 9}" {
+		t.Errorf("wrong order in copy. got=%s", copied.Inspect())
+	}
+}
+
+func TestNumberHashKey(t *testing.T) {
+	big1 := NewBigInteger(new(big.Int).Lsh(big.NewInt(1), 70))
+	big2 := NewBigInteger(new(big.Int).Lsh(big.NewInt(1), 70))
+	small := NewBigInteger(big.NewInt(42))
+
+	if big1.HashKey() != big2.HashKey() {
+		t.Errorf("big integers with same content have different hash keys")
+	}
+	if small.Big != nil || small.HashKey() != (&Integer{Value: 42}).HashKey() {
+		t.Errorf("small big.Int was not normalized. got=%+v", small)
+	}
+	if (&Float{Value: 1.5}).HashKey() != (&Float{Value: 1.5}).HashKey() {
+		t.Errorf("floats with same value have different hash keys")
+	}
+	if (&Float{Value: 42}).HashKey() != small.HashKey() || (&Float{Value: math.Ldexp(1, 70)}).HashKey() != big1.HashKey() {
+		t.Errorf("whole floats and the integers they equal have different hash keys")
+	}
+	if (&Float{Value: 1.5}).HashKey() == (&Integer{Value: 1}).HashKey() {
+		t.Errorf("a fraction has the hash key of an integer")
+	}
+	if (&Float{Value: 3}).Inspect() != "3.0" || big1.Inspect() != "1180591620717411303424" {
+		t.Errorf("wrong inspect. got=%s and %s", (&Float{Value: 3}).Inspect(), big1.Inspect())
+	}
+}
+
+func TestArrayVector(t *testing.T) {
+	const n = 5000
+
+	versions := []*Array{}
+	arr := NewArray(nil)
+	for i := 0; i < n; i++ {
+		arr = arr.Push(&Integer{Value: int64(i)})
+		if i == 31 || i == 32 || i == 1055 || i == 1056 {
+			versions = append(versions, arr)
+		}
+	}
+
+	if arr.Len() != n {
+		t.Fatalf("wrong length. got=%d", arr.Len())
+	}
+	for i := 0; i < n; i++ {
+		if got := arr.Get(i).(*Integer).Value; got != int64(i) {
+			t.Fatalf("element %d wrong. got=%d", i, got)
+		}
+	}
+	for _, version := range versions {
+		last := version.Get(version.Len() - 1).(*Integer).Value
+		if last != int64(version.Len()-1) {
+			t.Errorf("pushing changed an earlier version of length %d. got last=%d", version.Len(), last)
+		}
+	}
+
+	rest := arr.Slice(1, arr.Len())
+	middle := arr.Slice(100, 200).Push(&String{Value: "x"})
+	if rest.Len() != n-1 || rest.Get(0).Inspect() != "1" {
+		t.Errorf("wrong rest. got len=%d first=%s", rest.Len(), rest.Get(0).Inspect())
+	}
+	if middle.Len() != 101 || middle.Get(100).Inspect() != "x" || arr.Get(200).Inspect() != "200" {
+		t.Errorf("pushing onto a slice disturbed the original. got %s and %s", middle.Get(100).Inspect(), arr.Get(200).Inspect())
+	}
+}
+
+func TestHashTrie(t *testing.T) {
+	const n = 5000
+
+	hash := NewHash()
+	for i := 0; i < n; i++ {
+		key := &Integer{Value: int64(i)}
+		hash.Set(key.HashKey(), HashPair{Key: key, Value: key})
+	}
+	snapshot := hash.Copy()
+	for i := 0; i < n; i += 2 {
+		hash.Delete((&Integer{Value: int64(i)}).HashKey())
+	}
+
+	if hash.Len() != n/2 || snapshot.Len() != n {
+		t.Fatalf("wrong sizes. got=%d and %d", hash.Len(), snapshot.Len())
+	}
+	for i := 0; i < n; i++ {
+		_, ok := hash.Get((&Integer{Value: int64(i)}).HashKey())
+		if ok != (i%2 == 1) {
+			t.Fatalf("key %d present=%t after deleting even keys", i, ok)
+		}
+		if _, ok := snapshot.Get((&Integer{Value: int64(i)}).HashKey()); !ok {
+			t.Fatalf("deleting from a copy removed key %d from the original", i)
+		}
+	}
+	items := hash.Items()
+	if len(items) != n/2 || items[0].Key.Inspect() != "1" || items[len(items)-1].Key.Inspect() != "4999" {
+		t.Errorf("items out of order")
+	}
+
+	// TRUE and 1 have hash keys with equal values but different types
+	collide := NewHash()
+	one, yes := &Integer{Value: 1}, &Boolean{Value: true}
+	collide.Set(one.HashKey(), HashPair{Key: one, Value: &String{Value: "one"}})
+	collide.Set(yes.HashKey(), HashPair{Key: yes, Value: &String{Value: "yes"}})
+	collide.Delete(one.HashKey())
+	if pair, ok := collide.Get(yes.HashKey()); !ok || pair.Value.Inspect() != "yes" || collide.Len() != 1 {
+		t.Errorf("colliding keys were mixed up. got=%s", collide.Inspect())
+	}
+}
+
+// The benchmarks compare the persistent structures with copying a slice or map
+// on every update, which is how arrays and hashes used to be stored.
+
+func BenchmarkArrayPush(b *testing.B) {
+	const n = 1000
+
+	b.Run("vector", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			arr := NewArray(nil)
+			for j := 0; j < n; j++ {
+				arr = arr.Push(&Integer{Value: int64(j)})
+			}
+		}
+	})
+	b.Run("copy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			elements := []Object{}
+			for j := 0; j < n; j++ {
+				next := make([]Object, len(elements)+1)
+				copy(next, elements)
+				next[len(elements)] = &Integer{Value: int64(j)}
+				elements = next
+			}
+		}
+	})
+}
+
+func BenchmarkArrayRest(b *testing.B) {
+	const n = 1000
+	elements := make([]Object, n)
+	for i := range elements {
+		elements[i] = &Integer{Value: int64(i)}
+	}
+
+	b.Run("vector", func(b *testing.B) {
+		arr := NewArray(elements)
+		for i := 0; i < b.N; i++ {
+			for rest := arr; rest.Len() > 0; rest = rest.Slice(1, rest.Len()) {
+			}
+		}
+	})
+	b.Run("copy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for rest := elements; len(rest) > 0; {
+				next := make([]Object, len(rest)-1)
+				copy(next, rest[1:])
+				rest = next
+			}
+		}
+	})
+}
+
+func BenchmarkHashSet(b *testing.B) {
+	const n = 1000
+	keys := make([]*Integer, n)
+	for i := range keys {
+		keys[i] = &Integer{Value: int64(i)}
+	}
+
+	b.Run("trie", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hash := NewHash()
+			for _, key := range keys {
+				hash = hash.Copy()
+				hash.Set(key.HashKey(), HashPair{Key: key, Value: key})
+			}
+		}
+	})
+	b.Run("copy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pairs, order := map[HashKey]HashPair{}, []HashKey{}
+			for _, key := range keys {
+				next := make(map[HashKey]HashPair, len(pairs)+1)
+				for k, pair := range pairs {
+					next[k] = pair
+				}
+				pairs, order = next, append(order[:len(order):len(order)], key.HashKey())
+				pairs[key.HashKey()] = HashPair{Key: key, Value: key}
+			}
+		}
+	})
+}
+
+func TestLocalEnvironment(t *testing.T) {
+	global := NewEnvironment()
+	global.Set("g", &Integer{Value: 1})
+	local := NewLocalEnvironment(global, []string{"a", "b"})
+	local.SetSlot(0, &Integer{Value: 2})
+	local.Set("c", &Integer{Value: 3})
+
+	if names := local.Names(); len(names) != 2 || names[0] != "a" || names[1] != "c" {
+		t.Errorf("wrong names. got=%v", names)
+	}
+	if _, ok := local.GetSlot(0, 1); ok {
+		t.Errorf("unset slot reported as set")
+	}
+	if obj, ok := local.GetSlot(1, 0); ok {
+		t.Errorf("global has no slots. got=%v", obj)
+	}
+
+	if local.Assign("b", &Integer{Value: 4}) {
+		t.Errorf("assigned to an unset slot")
+	}
+	if !local.Assign("a", &Integer{Value: 5}) || !local.Assign("g", &Integer{Value: 6}) {
+		t.Fatalf("failed to assign existing bindings")
+	}
+	if obj, _ := local.Get("a"); obj.(*Integer).Value != 5 {
+		t.Errorf("a has wrong value. got=%v", obj)
+	}
+	if obj, _ := global.Get("g"); obj.(*Integer).Value != 6 {
+		t.Errorf("g has wrong value. got=%v", obj)
+	}
+}
@@ -0,0 +1,123 @@
+package object
+
+const (
+	vectorBits  = 5
+	vectorWidth = 1 << vectorBits
+	vectorMask  = vectorWidth - 1
+)
+
+// vector is a persistent vector: a trie of 32-way nodes whose leaves hold the
+// elements, plus a tail of up to 32 elements not yet moved into the trie.
+// Updates return a new vector sharing every unchanged node with the old one,
+// so they take O(log32 n) time and never disturb existing vectors.
+type vector struct {
+	count int
+	shift uint // the level of the root
+	root  *vectorNode
+	tail  []Object
+}
+
+// vectorNode is a branch of the trie, holding children, or a leaf, holding values
+type vectorNode struct {
+	children []*vectorNode
+	values   []Object
+}
+
+var emptyVector = &vector{shift: vectorBits, root: &vectorNode{}}
+
+// tailOffset is the index of the first element in the tail
+func (v *vector) tailOffset() int {
+	if v.count < vectorWidth {
+		return 0
+	}
+	return ((v.count - 1) >> vectorBits) << vectorBits
+}
+
+// get returns element i, which must be in range
+func (v *vector) get(i int) Object {
+	if i >= v.tailOffset() {
+		return v.tail[i&vectorMask]
+	}
+
+	node := v.root
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.children[(i>>level)&vectorMask]
+	}
+	return node.values[i&vectorMask]
+}
+
+// push returns v with val appended
+func (v *vector) push(val Object) *vector {
+	if v.count-v.tailOffset() < vectorWidth {
+		tail := make([]Object, len(v.tail)+1)
+		copy(tail, v.tail)
+		tail[len(v.tail)] = val
+		return &vector{count: v.count + 1, shift: v.shift, root: v.root, tail: tail}
+	}
+
+	// the tail is full, so it becomes a leaf of the trie
+	leaf := &vectorNode{values: v.tail}
+	root, shift := v.root, v.shift
+	if v.count>>vectorBits > 1<<shift {
+		root = &vectorNode{children: []*vectorNode{v.root, newVectorPath(shift, leaf)}}
+		shift += vectorBits
+	} else {
+		root = v.pushLeaf(shift, v.root, leaf)
+	}
+	return &vector{count: v.count + 1, shift: shift, root: root, tail: []Object{val}}
+}
+
+// pushLeaf returns a copy of parent, a branch at level, with leaf added after
+// its last element
+func (v *vector) pushLeaf(level uint, parent, leaf *vectorNode) *vectorNode {
+	idx := ((v.count - 1) >> level) & vectorMask
+
+	var child *vectorNode
+	switch {
+	case level == vectorBits:
+		child = leaf
+	case idx < len(parent.children):
+		child = v.pushLeaf(level-vectorBits, parent.children[idx], leaf)
+	default:
+		child = newVectorPath(level-vectorBits, leaf)
+	}
+
+	children := make([]*vectorNode, idx+1)
+	copy(children, parent.children[:idx])
+	children[idx] = child
+	return &vectorNode{children: children}
+}
+
+// newVectorPath returns node wrapped in branches up to level
+func newVectorPath(level uint, node *vectorNode) *vectorNode {
+	if level == 0 {
+		return node
+	}
+	return &vectorNode{children: []*vectorNode{newVectorPath(level-vectorBits, node)}}
+}
+
+// set returns v with element i, which must be in range, replaced by val
+func (v *vector) set(i int, val Object) *vector {
+	if i >= v.tailOffset() {
+		tail := make([]Object, len(v.tail))
+		copy(tail, v.tail)
+		tail[i&vectorMask] = val
+		return &vector{count: v.count, shift: v.shift, root: v.root, tail: tail}
+	}
+	return &vector{count: v.count, shift: v.shift, root: setVectorNode(v.shift, v.root, i, val), tail: v.tail}
+}
+
+func setVectorNode(level uint, node *vectorNode, i int, val Object) *vectorNode {
+	if level == 0 {
+		values := make([]Object, len(node.values))
+		copy(values, node.values)
+		values[i&vectorMask] = val
+		return &vectorNode{values: values}
+	}
+
+	idx := (i >> level) & vectorMask
+	children := make([]*vectorNode, len(node.children))
+	copy(children, node.children)
+	children[idx] = setVectorNode(level-vectorBits, node.children[idx], i, val)
+	return &vectorNode{children: children}
+}
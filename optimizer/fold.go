@@ -0,0 +1,122 @@
+package optimizer
+
+import (
+	"math"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/object"
+	"monkey_interpreter/token"
+	"strconv"
+	"strings"
+)
+
+// Fold replaces operators applied to literals with the literal they evaluate
+// to, so `2 * 60 * 60` becomes `7200` and `"a" + "b"` becomes `"ab"`, and
+// strings interpolating only literals with the string they make. The
+// evaluator does the arithmetic, so folded values are exactly what running
+// the program would produce. Operations that fail, like dividing by zero, and
+// results with no literal form, like integers beyond 64 bits, are left for
+// run time.
+//
+// String literals at the end of a chain of + are joined too: `s + "a" + "b"`
+// becomes `s + "ab"`. Whatever s is, adding a string to it gives the same
+// string or the same type mismatch error either way.
+func Fold(program *ast.Program) {
+	r := &rewriter{expression: fold}
+	r.program(program)
+}
+
+func fold(exp ast.Expression) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.PrefixExpression:
+		if isConstant(exp.Right) {
+			return evaluate(exp)
+		}
+	case *ast.InfixExpression:
+		if isConstant(exp.Left) && isConstant(exp.Right) {
+			return evaluate(exp)
+		}
+		return joinStrings(exp)
+	case *ast.IndexExpression:
+		if isConstant(exp.Left) && isConstant(exp.Index) {
+			return evaluate(exp)
+		}
+	case *ast.InterpolatedString:
+		for _, part := range exp.Parts {
+			if !isConstant(part) {
+				return exp
+			}
+		}
+		return evaluate(exp)
+	}
+	return exp
+}
+
+// joinStrings turns (left + "a") + "b" into left + "ab"
+func joinStrings(exp *ast.InfixExpression) ast.Expression {
+	right, ok := exp.Right.(*ast.StringLiteral)
+	if !ok || exp.Operator != "+" {
+		return exp
+	}
+	left, ok := exp.Left.(*ast.InfixExpression)
+	if !ok || left.Operator != "+" {
+		return exp
+	}
+	inner, ok := left.Right.(*ast.StringLiteral)
+	if !ok {
+		return exp
+	}
+
+	left.Right = stringLiteral(inner.Token, inner.Value+right.Value)
+	return left
+}
+
+// isConstant reports whether exp is a literal with no parts to evaluate
+func isConstant(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	}
+	return false
+}
+
+// evaluate returns the literal exp evaluates to, or exp itself when there is none
+func evaluate(exp ast.Expression) ast.Expression {
+	tok := ast.TokenOf(exp)
+
+	switch value := evaluator.Eval(exp, object.NewEnvironment()).(type) {
+	case *object.Integer:
+		tok.Type, tok.Literal = token.INT, value.Inspect()
+		return &ast.IntegerLiteral{Token: tok, Value: value.Value, Big: value.Big}
+	case *object.Float:
+		if math.IsInf(value.Value, 0) || math.IsNaN(value.Value) {
+			return exp
+		}
+		tok.Type, tok.Literal = token.FLOAT, floatLiteral(value.Value)
+		return &ast.FloatLiteral{Token: tok, Value: value.Value}
+	case *object.String:
+		return stringLiteral(tok, value.Value)
+	case *object.Boolean:
+		if value.Value {
+			tok.Type, tok.Literal = token.TRUE, "true"
+		} else {
+			tok.Type, tok.Literal = token.FALSE, "false"
+		}
+		return &ast.Boolean{Token: tok, Value: value.Value}
+	}
+	return exp
+}
+
+func stringLiteral(tok token.Token, value string) *ast.StringLiteral {
+	tok.Type, tok.Literal = token.STRING, value
+	return &ast.StringLiteral{Token: tok, Value: value}
+}
+
+// floatLiteral writes f the way the lexer reads floats, which is without exponents
+func floatLiteral(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
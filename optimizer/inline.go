@@ -0,0 +1,169 @@
+package optimizer
+
+import "monkey_interpreter/ast"
+
+// maxInlineNodes is the most nodes the body of a function may have to be inlined
+const maxInlineNodes = 16
+
+// Inline replaces calls of small functions with their bodies, the arguments
+// put in place of the parameters, so `let sq = fn(x) { x * x }; sq(3)` ends
+// with `3 * 3`. Only calls that leave nothing to tell the difference are
+// replaced:
+//
+//   - the function is bound by a let at the top of the program, and nothing
+//     else anywhere binds that name, so every later use means the function
+//   - the call comes after that let and passes one argument per parameter
+//   - the body is a single expression of at most maxInlineNodes nodes that
+//     uses no names but the parameters, so it cannot recurse or depend on
+//     where it runs
+//   - every argument is a literal or a parameter of a function the call is
+//     in, both of which always evaluate to the same value without failing,
+//     so evaluating one any number of times is the same as doing it once
+func Inline(program *ast.Program) {
+	bindings := make(map[string]int)
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			if n.Name != nil {
+				bindings[n.Name.Value]++
+			}
+		case *ast.BindingPattern:
+			bindings[n.Name.Value]++
+		case *ast.FunctionLiteral:
+			for _, param := range n.Parameters {
+				bindings[param.Value]++
+			}
+		}
+		return true
+	})
+
+	inlinable := make(map[string]*ast.FunctionLiteral)
+	r := &rewriter{}
+	r.expression = func(exp ast.Expression) ast.Expression {
+		call, ok := exp.(*ast.CallExpression)
+		if !ok {
+			return exp
+		}
+		name, ok := call.Function.(*ast.Identifier)
+		if !ok || inlinable[name.Value] == nil {
+			return exp
+		}
+		fn := inlinable[name.Value]
+		if len(call.Arguments) != len(fn.Parameters) {
+			return exp
+		}
+
+		args := make(map[string]ast.Expression, len(fn.Parameters))
+		for i, arg := range call.Arguments {
+			if !isConstant(arg) && !isParameter(arg, r.functions) {
+				return exp
+			}
+			args[fn.Parameters[i].Value] = arg
+		}
+		return substitute(fn.Body.Statements[0].(*ast.ExpressionStatement).Expression, args)
+	}
+
+	for _, s := range program.Statements {
+		r.statement(s)
+		if let, ok := s.(*ast.LetStatement); ok && let.Name != nil && bindings[let.Name.Value] == 1 {
+			if fn, ok := let.Value.(*ast.FunctionLiteral); ok && isInlinable(fn) {
+				inlinable[let.Name.Value] = fn
+			}
+		}
+	}
+}
+
+// isInlinable reports whether fn is small enough and uses nothing but its
+// parameters, which are all plain names without defaults
+func isInlinable(fn *ast.FunctionLiteral) bool {
+	if fn.Body == nil || len(fn.Body.Statements) != 1 {
+		return false
+	}
+	if fn.ParameterPatterns != nil || fn.ParameterDefaults != nil || fn.Variadic {
+		return false
+	}
+	s, ok := fn.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok || s.Expression == nil {
+		return false
+	}
+
+	params := make(map[string]bool, len(fn.Parameters))
+	for _, param := range fn.Parameters {
+		params[param.Value] = true
+	}
+	nodes, ok := 0, true
+	ast.Inspect(s.Expression, func(n ast.Node) bool {
+		nodes++
+		switch n := n.(type) {
+		case *ast.Identifier:
+			ok = ok && params[n.Value]
+		case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean,
+			*ast.PrefixExpression, *ast.InfixExpression, *ast.CallExpression,
+			*ast.ArrayLiteral, *ast.IndexExpression:
+		default:
+			ok = false
+		}
+		return ok
+	})
+	return ok && nodes <= maxInlineNodes
+}
+
+// isParameter reports whether exp names a parameter of one of functions
+func isParameter(exp ast.Expression, functions []*ast.FunctionLiteral) bool {
+	ident, ok := exp.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+	for _, fn := range functions {
+		for _, param := range fn.Parameters {
+			if param.Value == ident.Value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// substitute returns a copy of exp, which isInlinable accepted, with a copy of
+// args[name] in place of every identifier name
+func substitute(exp ast.Expression, args map[string]ast.Expression) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		arg := args[exp.Value]
+		if ident, ok := arg.(*ast.Identifier); ok {
+			return &ast.Identifier{Token: ident.Token, Value: ident.Value}
+		}
+		return substitute(arg, nil)
+	case *ast.IntegerLiteral:
+		copied := *exp
+		return &copied
+	case *ast.FloatLiteral:
+		copied := *exp
+		return &copied
+	case *ast.StringLiteral:
+		copied := *exp
+		return &copied
+	case *ast.Boolean:
+		copied := *exp
+		return &copied
+	case *ast.PrefixExpression:
+		return &ast.PrefixExpression{Token: exp.Token, Operator: exp.Operator, Right: substitute(exp.Right, args)}
+	case *ast.InfixExpression:
+		return &ast.InfixExpression{Token: exp.Token, Left: substitute(exp.Left, args), Operator: exp.Operator, Right: substitute(exp.Right, args)}
+	case *ast.CallExpression:
+		return &ast.CallExpression{Token: exp.Token, Function: substitute(exp.Function, args), Arguments: substituteAll(exp.Arguments, args)}
+	case *ast.ArrayLiteral:
+		return &ast.ArrayLiteral{Token: exp.Token, Elements: substituteAll(exp.Elements, args)}
+	case *ast.IndexExpression:
+		return &ast.IndexExpression{Token: exp.Token, Left: substitute(exp.Left, args), Index: substitute(exp.Index, args)}
+	}
+	return exp
+}
+
+func substituteAll(exps []ast.Expression, args map[string]ast.Expression) []ast.Expression {
+	copied := make([]ast.Expression, len(exps))
+	for i, exp := range exps {
+		copied[i] = substitute(exp, args)
+	}
+	return copied
+}
@@ -0,0 +1,136 @@
+package optimizer
+
+import "monkey_interpreter/ast"
+
+// Pass rewrites a program in place into one that evaluates to the same result
+type Pass func(program *ast.Program)
+
+// Passes are the passes Optimize runs, in order. Inlining comes first so the
+// calls it replaces can be folded, and folding before pruning turns
+// conditions like `1 < 2` into the literals pruning looks for.
+var Passes = []Pass{Inline, Fold, Prune}
+
+// Optimize runs every pass over program. It has to happen before the program
+// is resolved, since the passes add, move and remove identifiers.
+func Optimize(program *ast.Program) {
+	for _, pass := range Passes {
+		pass(program)
+	}
+}
+
+// rewriter walks a tree children first, replacing every expression with what
+// expression returns for it and every list of statements with what statements
+// returns for it. functions holds the function literals being walked through,
+// innermost last.
+type rewriter struct {
+	expression func(ast.Expression) ast.Expression
+	statements func([]ast.Statement) []ast.Statement
+	functions  []*ast.FunctionLiteral
+}
+
+func (r *rewriter) program(program *ast.Program) {
+	program.Statements = r.list(program.Statements)
+}
+
+func (r *rewriter) list(stmts []ast.Statement) []ast.Statement {
+	for _, s := range stmts {
+		r.statement(s)
+	}
+	if r.statements != nil {
+		stmts = r.statements(stmts)
+	}
+	return stmts
+}
+
+func (r *rewriter) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		s.Value = r.rewrite(s.Value)
+	case *ast.ReturnStatement:
+		s.ReturnValue = r.rewrite(s.ReturnValue)
+	case *ast.YieldStatement:
+		s.Value = r.rewrite(s.Value)
+	case *ast.ExpressionStatement:
+		s.Expression = r.rewrite(s.Expression)
+	case *ast.BlockStatement:
+		r.block(s)
+	}
+}
+
+func (r *rewriter) block(b *ast.BlockStatement) {
+	if b != nil {
+		b.Statements = r.list(b.Statements)
+	}
+}
+
+// rewrite guards against the nil expressions of omitted parts and parser errors
+func (r *rewriter) rewrite(exp ast.Expression) ast.Expression {
+	if exp == nil {
+		return nil
+	}
+
+	switch exp := exp.(type) {
+	case *ast.PrefixExpression:
+		exp.Right = r.rewrite(exp.Right)
+	case *ast.InfixExpression:
+		exp.Left = r.rewrite(exp.Left)
+		exp.Right = r.rewrite(exp.Right)
+	case *ast.IfExpression:
+		exp.Condition = r.rewrite(exp.Condition)
+		r.block(exp.Consequence)
+		r.block(exp.Alternative)
+	case *ast.ForExpression:
+		exp.Iterable = r.rewrite(exp.Iterable)
+		r.block(exp.Body)
+	case *ast.FunctionLiteral:
+		r.functions = append(r.functions, exp)
+		r.rewriteAll(exp.ParameterDefaults)
+		r.block(exp.Body)
+		r.functions = r.functions[:len(r.functions)-1]
+	case *ast.CallExpression:
+		exp.Function = r.rewrite(exp.Function)
+		r.rewriteAll(exp.Arguments)
+	case *ast.ArrayLiteral:
+		r.rewriteAll(exp.Elements)
+	case *ast.IndexExpression:
+		exp.Left = r.rewrite(exp.Left)
+		exp.Index = r.rewrite(exp.Index)
+	case *ast.SliceExpression:
+		exp.Left = r.rewrite(exp.Left)
+		exp.Start = r.rewrite(exp.Start)
+		exp.End = r.rewrite(exp.End)
+	case *ast.InterpolatedString:
+		r.rewriteAll(exp.Parts)
+	case *ast.SpreadExpression:
+		exp.Value = r.rewrite(exp.Value)
+	case *ast.NamedArgument:
+		exp.Value = r.rewrite(exp.Value)
+	case *ast.MemberExpression:
+		exp.Object = r.rewrite(exp.Object)
+	case *ast.HashLiteral:
+		pairs := make(map[ast.Expression]ast.Expression, len(exp.Pairs))
+		for i, key := range exp.Keys {
+			value := exp.Pairs[key]
+			exp.Keys[i] = r.rewrite(key)
+			pairs[exp.Keys[i]] = r.rewrite(value)
+		}
+		exp.Pairs = pairs
+	case *ast.MatchExpression:
+		exp.Subject = r.rewrite(exp.Subject)
+		for _, arm := range exp.Arms {
+			arm.Guard = r.rewrite(arm.Guard)
+			arm.Result = r.rewrite(arm.Result)
+		}
+	}
+
+	if r.expression != nil {
+		return r.expression(exp)
+	}
+	return exp
+}
+
+func (r *rewriter) rewriteAll(exps []ast.Expression) {
+	for i, exp := range exps {
+		exps[i] = r.rewrite(exp)
+	}
+}
@@ -0,0 +1,119 @@
+package optimizer
+
+import (
+	"monkey_interpreter/ast"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/format"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/resolver"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+func TestPasses(t *testing.T) {
+	tests := []struct {
+		pass     Pass
+		input    string
+		expected string
+	}{
+		{Fold, "2 * 60 * 60", "7200;"},
+		{Fold, "-(1 + 2); !true; 1 < 2; 7 / 2; 1.5 * 2", "-3;\nfalse;\ntrue;\n3;\n3.0;"},
+		{Fold, `"a" + "b" == "ab"; "abc"[1]`, "true;\n\"b\";"},
+		{Fold, `s + "a" + "b" + "c"; "a" + s + "b"`, "s + \"abc\";\n\"a\" + s + \"b\";"},
+		{Fold, "x * 2 * 3; 1 / 0; 1 + true", "x * 2 * 3;\n1 / 0;\n1 + true;"},
+		{Fold, "9223372036854775807 + 1; -9223372036854775808", "9223372036854775808;\n-9223372036854775808;"},
+		{Fold, "fn(x) { x + (1 + 2) }", "fn(x) {\n    x + 3\n};"},
+		{Fold, `"${1 + 1} and ${"b"}"; "${x} ${1 + 1}"`, "\"2 and b\";\n\"${x} ${2}\";"},
+
+		{Prune, "if (true) { a } else { b }", "a;"},
+		{Prune, "let x = if (false) { a } else { b };", "let x = b;"},
+		{Prune, "if (1) { let a = 1; a }; 2", "let a = 1;\na;\n2;"},
+		{Prune, "if (false) { a }; 2", "2;"},
+		{Prune, "1; if (false) { a }", "1;\nif (false) {\n    a\n}"},
+		{Prune, "let x = if (false) { a };", "let x = if (false) {\n    a\n};"},
+		{Prune, "if (x) { a } else { b }", "if (x) {\n    a\n} else {\n    b\n}"},
+
+		{Inline, "let sq = fn(x) { x * x }; sq(3)", "let sq = fn(x) {\n    x * x\n};\n3 * 3;"},
+		{Inline, "let sq = fn(x) { x * x }; fn(n) { sq(n) }", "let sq = fn(x) {\n    x * x\n};\nfn(n) {\n    n * n\n};"},
+		{Inline, "let sq = fn(x) { x * x }; sq(n)", "let sq = fn(x) {\n    x * x\n};\nsq(n);"},
+		{Inline, "let sq = fn(x) { x * x }; sq(f())", "let sq = fn(x) {\n    x * x\n};\nsq(f());"},
+		{Inline, "sq(3); let sq = fn(x) { x * x };", "sq(3);\nlet sq = fn(x) {\n    x * x\n};"},
+		{Inline, "let sq = fn(x) { x * x }; let sq = 1; sq(3)", "let sq = fn(x) {\n    x * x\n};\nlet sq = 1;\nsq(3);"},
+		{Inline, "let sq = fn(x) { x * x }; sq(1, 2)", "let sq = fn(x) {\n    x * x\n};\nsq(1, 2);"},
+		{Inline, "let f = fn(x) { f(x) }; f(1)", "let f = fn(x) {\n    f(x)\n};\nf(1);"},
+		{Inline, "let f = fn(x) { x + y }; f(1)", "let f = fn(x) {\n    x + y\n};\nf(1);"},
+		{Inline, "let f = fn(x) { let y = x; y }; f(1)", "let f = fn(x) {\n    let y = x;\n    y\n};\nf(1);"},
+		{Inline, "if (a) { let sq = fn(x) { x * x } }; sq(1)", "if (a) {\n    let sq = fn(x) {\n        x * x\n    };\n}\nsq(1);"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		tt.pass(program)
+		if got := format.Node(program, format.Default); got != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%q\ngot= %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestOptimizePreservesResults(t *testing.T) {
+	tests := []string{
+		"2 * 60 * 60",
+		"let sq = fn(x) { x * x }; let f = fn(n) { sq(n) + sq(2) }; [f(3), sq(1.5)]",
+		`let greet = fn(name) { "hi " + name + "!" + "!" }; greet("bob")`,
+		`let greet = fn(name) { "hi " + name + "!" + "!" }; greet(1)`,
+		`let s = 5; s + "a" + "b"`,
+		"let sq = fn(x) { x * x }; sq(true)",
+		"let sq = fn(x) { x * x }; sq(1, 2)",
+		"let div = fn(a, b) { a / b }; div(1, 0)",
+		"sq(3); let sq = fn(x) { x * x };",
+		"let first = fn(a, b) { a }; let g = fn(n) { first(n, n) }; g(7)",
+		"let apply = fn(f, x) { f(x) }; let g = fn(f) { apply(f, 2) }; g(fn(x) { x * 10 })",
+		"let x = if (1 > 2) { 1 } else { let y = 2; y * 3 }; x + y",
+		"let f = fn() { if (true) { return 1; }; 2 }; f()",
+		"if (false) { 1 }",
+		"let f = fn() { if (false) { 1 } }; f()",
+		"9223372036854775807 * 2 + 1",
+		"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(10)",
+		`let h = {"a": 1 + 1}; h["a"] + h.a`,
+		"[1, 2, 3][-1] + [1, 2, 3][0:2][1]",
+		"let one = fn([a]) { 1 }; one([1, 2])",
+		`let sq = fn(x) { x * x }; "${sq(3)} ${1.5 * 2} ${[1, 2]}"`,
+		"let [a, b] = [1, 2]; let a = fn(x) { x }; a(b)",
+		"let sq = fn(x) { x * x }; let g = fn() { for (i in range(2 + 1)) { if (true) { yield sq(i) } } }; collect(g())",
+		"let sq = fn(x) { x * x }; let total = 0; for (x in [1, 2]) { let sq = fn(x) { x }; let total = total + sq(x) }; total",
+	}
+
+	for _, input := range tests {
+		expected := run(parse(t, input))
+
+		program := parse(t, input)
+		Optimize(program)
+		if got := run(program); got != expected {
+			t.Errorf("optimizing changed the result of %q.\nwant=%s\ngot= %s\noptimized:\n%s",
+				input, expected, got, format.Node(program, format.Default))
+		}
+	}
+}
+
+func run(program *ast.Program) string {
+	resolver.Resolve(program)
+	var out strings.Builder
+	in := evaluator.New()
+	in.Out = &out
+	evaluated := in.Eval(program, object.NewEnvironment())
+	if evaluated == nil {
+		return out.String() + "<nil>"
+	}
+	return out.String() + evaluated.Inspect()
+}
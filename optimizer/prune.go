@@ -0,0 +1,71 @@
+package optimizer
+
+import "monkey_interpreter/ast"
+
+// Prune removes the branches of if expressions whose condition is a literal
+// and so never taken. An if used as a statement is replaced by the statements
+// of the branch taken, or dropped when no branch is and its value is unused.
+// One used as a value is replaced when the branch taken is a single
+// expression. Blocks share the environment they run in, so lets keep binding
+// the same names once moved out of them.
+func Prune(program *ast.Program) {
+	r := &rewriter{expression: pruneExpression, statements: pruneStatements}
+	r.program(program)
+}
+
+func pruneExpression(exp ast.Expression) ast.Expression {
+	ie, ok := exp.(*ast.IfExpression)
+	if !ok {
+		return exp
+	}
+	taken, ok := branchTaken(ie)
+	if !ok || taken == nil || len(taken.Statements) != 1 {
+		return exp
+	}
+	if s, ok := taken.Statements[0].(*ast.ExpressionStatement); ok && s.Expression != nil {
+		return s.Expression
+	}
+	return exp
+}
+
+func pruneStatements(stmts []ast.Statement) []ast.Statement {
+	pruned := make([]ast.Statement, 0, len(stmts))
+	for i, s := range stmts {
+		last := i == len(stmts)-1
+
+		es, ok := s.(*ast.ExpressionStatement)
+		if !ok {
+			pruned = append(pruned, s)
+			continue
+		}
+		ie, ok := es.Expression.(*ast.IfExpression)
+		if !ok {
+			pruned = append(pruned, s)
+			continue
+		}
+		taken, ok := branchTaken(ie)
+		switch {
+		case !ok:
+			pruned = append(pruned, s)
+		case taken != nil && len(taken.Statements) > 0:
+			pruned = append(pruned, taken.Statements...)
+		case !last:
+			// nothing runs and the value is not the block's
+		default:
+			pruned = append(pruned, s)
+		}
+	}
+	return pruned
+}
+
+// branchTaken returns the branch ie always takes, which is nil when it takes
+// none, and reports whether the condition is known
+func branchTaken(ie *ast.IfExpression) (*ast.BlockStatement, bool) {
+	if !isConstant(ie.Condition) {
+		return nil, false
+	}
+	if b, ok := ie.Condition.(*ast.Boolean); ok && !b.Value {
+		return ie.Alternative, true
+	}
+	return ie.Consequence, true
+}
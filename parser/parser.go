@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"monkey_interpreter/ast"
 	"monkey_interpreter/lexer"
 	"monkey_interpreter/token"
@@ -33,24 +34,36 @@ var precedences = map[token.TokenType]int{
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
+	token.IN:       LESSGREATER,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
+}
+
+// Error is a parser error along with the token it was reported at
+type Error struct {
+	Token   token.Token
+	Message string
 }
 
 // Parser struct
 type Parser struct {
 	l      *lexer.Lexer
-	errors []string
+	errors []Error
 
 	curToken  token.Token
 	peekToken token.Token
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	// yielded is set once a yield is parsed in the body of the innermost
+	// function literal being parsed, and is nil outside of any
+	yielded *bool
 }
 
 // prefix and infix parsing
@@ -61,7 +74,7 @@ type (
 
 // New creates a new Parser object
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l, errors: []Error{}}
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -70,6 +83,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
@@ -78,8 +92,11 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.STRING_START, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.FOR, p.parseForExpression)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -89,21 +106,36 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 
 	return p
 }
 
 // Errors function to return parsing errors
 func (p *Parser) Errors() []string {
+	msgs := make([]string, len(p.errors))
+	for i, err := range p.errors {
+		msgs[i] = err.Message
+	}
+	return msgs
+}
+
+// DetailedErrors returns parsing errors along with where they occurred
+func (p *Parser) DetailedErrors() []Error {
 	return p.errors
 }
 
+func (p *Parser) addError(tok token.Token, msg string) {
+	p.errors = append(p.errors, Error{Token: tok, Message: msg})
+}
+
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken, msg)
 }
 
 func (p *Parser) nextToken() {
@@ -130,9 +162,15 @@ func (p *Parser) ParseProgram() *ast.Program {
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
-		return p.parseLetStatement()
+		// avoid wrapping a nil *ast.LetStatement in a non-nil interface
+		if stmt := p.parseLetStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.YIELD:
+		return p.parseYieldStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -141,11 +179,25 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		if stmt.Pattern = p.parsePattern(); stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		p.nextToken()
+		if stmt.Type = p.parseType(); stmt.Type == nil {
+			return nil
+		}
+	}
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -193,6 +245,27 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+// parseYieldStatement parses yield value, which turns the function it is in
+// into a generator
+func (p *Parser) parseYieldStatement() *ast.YieldStatement {
+	stmt := &ast.YieldStatement{Token: p.curToken}
+	if p.yielded == nil {
+		p.addError(p.curToken, "yield outside a function")
+	} else {
+		*p.yielded = true
+	}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
@@ -244,8 +317,28 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		huge, ok := new(big.Int).SetString(p.curToken.Literal, 0)
+		if !ok {
+			msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+			p.addError(p.curToken, msg)
+			return nil
+		}
+		lit.Big = huge
+		return lit
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.addError(p.curToken, msg)
 		return nil
 	}
 
@@ -256,7 +349,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.addError(p.curToken, msg)
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
@@ -351,6 +444,32 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+// parseForExpression parses for (pattern in iterable) { body }
+func (p *Parser) parseForExpression() ast.Expression {
+	exp := &ast.ForExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	if exp.Pattern = p.parsePattern(); exp.Pattern == nil {
+		return nil
+	}
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	exp.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	exp.Body = p.parseBlockStatement()
+
+	return exp
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
@@ -366,6 +485,8 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		p.nextToken()
 	}
 
+	block.Rbrace = p.curToken
+
 	return block
 }
 
@@ -376,50 +497,192 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	if !p.parseFunctionParameters(lit) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+		p.nextToken()
+		if lit.ReturnType = p.parseType(); lit.ReturnType == nil {
+			return nil
+		}
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 
+	outer, yielded := p.yielded, false
+	p.yielded = &yielded
 	lit.Body = p.parseBlockStatement()
+	p.yielded = outer
+	lit.Generator = yielded
 
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+// parseFunctionParameters parses the parameters of lit with their patterns,
+// annotations and defaults, leaving each of those nil unless at least one
+// parameter has one. Parameters with defaults must come after those without,
+// and a rest parameter, ...name, last.
+func (p *Parser) parseFunctionParameters(lit *ast.FunctionLiteral) bool {
+	lit.Parameters = []*ast.Identifier{}
+	types := []ast.TypeExpression{}
+	patterns := []ast.Pattern{}
+	defaults := []ast.Expression{}
+	annotated, destructured, defaulted := false, false, false
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return true
 	}
 
-	p.nextToken()
+	for {
+		p.nextToken()
+		if lit.Variadic {
+			p.addError(p.curToken, "the rest parameter must be the last parameter")
+			return false
+		}
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+		var pattern ast.Pattern
+		switch {
+		case p.curTokenIs(token.ELLIPSIS):
+			if !p.expectPeek(token.IDENT) {
+				return false
+			}
+			lit.Variadic = true
+			lit.Parameters = append(lit.Parameters, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		case p.curTokenIs(token.LBRACKET) || p.curTokenIs(token.LBRACE):
+			if pattern = p.parsePattern(); pattern == nil {
+				return false
+			}
+			destructured = true
+			lit.Parameters = append(lit.Parameters, &ast.Identifier{Token: ast.TokenOf(pattern), Value: pattern.String()})
+		default:
+			lit.Parameters = append(lit.Parameters, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		}
+		patterns = append(patterns, pattern)
+		param := lit.Parameters[len(lit.Parameters)-1]
+
+		var t ast.TypeExpression
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			if t = p.parseType(); t == nil {
+				return false
+			}
+			annotated = true
+		}
+		types = append(types, t)
+
+		var d ast.Expression
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			if lit.Variadic {
+				p.addError(p.curToken, "the rest parameter cannot have a default")
+				return false
+			}
+			p.nextToken()
+			if d = p.parseExpression(LOWEST); d == nil {
+				return false
+			}
+			defaulted = true
+		} else if defaulted && !lit.Variadic {
+			p.addError(param.Token, fmt.Sprintf("parameter %s needs a default, as it follows one with a default", param.Value))
+			return false
+		}
+		defaults = append(defaults, d)
 
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
 	}
 
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return false
 	}
 
-	return identifiers
+	if annotated {
+		lit.ParameterTypes = types
+	}
+	if destructured {
+		lit.ParameterPatterns = patterns
+	}
+	if defaulted {
+		lit.ParameterDefaults = defaults
+	}
+	return true
+}
+
+// parseType parses a type annotation starting at the current token: a name,
+// [element], {key: value} or fn(parameters) -> result
+func (p *Parser) parseType() ast.TypeExpression {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+
+	case token.LBRACKET:
+		t := &ast.ArrayType{Token: p.curToken}
+		p.nextToken()
+		if t.Element = p.parseType(); t.Element == nil || !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return t
+
+	case token.LBRACE:
+		t := &ast.HashType{Token: p.curToken}
+		p.nextToken()
+		if t.Key = p.parseType(); t.Key == nil || !p.expectPeek(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		if t.Value = p.parseType(); t.Value == nil || !p.expectPeek(token.RBRACE) {
+			return nil
+		}
+		return t
+
+	case token.FUNCTION:
+		t := &ast.FunctionType{Token: p.curToken, Parameters: []ast.TypeExpression{}}
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		for !p.peekTokenIs(token.RPAREN) {
+			if len(t.Parameters) > 0 && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+			p.nextToken()
+			param := p.parseType()
+			if param == nil {
+				return nil
+			}
+			t.Parameters = append(t.Parameters, param)
+		}
+		p.nextToken()
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		p.nextToken()
+		if t.Result = p.parseType(); t.Result == nil {
+			return nil
+		}
+		return t
+
+	default:
+		p.addError(p.curToken, fmt.Sprintf("expected a type, got %s instead", p.curToken.Type))
+		return nil
+	}
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Arguments = p.parseCallArguments()
 	return exp
 }
 
+// parseCallArguments parses a list of arguments, which besides expressions
+// may be ...array to spread an array or name: value to name a parameter
 func (p *Parser) parseCallArguments() []ast.Expression {
 	args := []ast.Expression{}
 
@@ -429,12 +692,12 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 	}
 
 	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	args = append(args, p.parseCallArgument())
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
+		args = append(args, p.parseCallArgument())
 	}
 
 	if !p.expectPeek(token.RPAREN) {
@@ -444,10 +707,58 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 	return args
 }
 
+func (p *Parser) parseCallArgument() ast.Expression {
+	switch {
+	case p.curTokenIs(token.ELLIPSIS):
+		spread := &ast.SpreadExpression{Token: p.curToken}
+		p.nextToken()
+		spread.Value = p.parseExpression(LOWEST)
+		return spread
+
+	case p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON):
+		name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.nextToken()
+		named := &ast.NamedArgument{Token: p.curToken, Name: name}
+		p.nextToken()
+		named.Value = p.parseExpression(LOWEST)
+		return named
+	}
+
+	return p.parseExpression(LOWEST)
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+// parseInterpolatedString parses the parts of a string from its STRING_START
+// to its STRING_END, keeping the text between expressions as string literals
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	exp := &ast.InterpolatedString{Token: p.curToken}
+	exp.Parts = []ast.Expression{&ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}}
+
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.STRING_MIDDLE) || p.curTokenIs(token.STRING_END) {
+			p.addError(p.curToken, "expected an expression inside ${}")
+			return nil
+		}
+		exp.Parts = append(exp.Parts, p.parseExpression(LOWEST))
+
+		p.nextToken()
+		switch p.curToken.Type {
+		case token.STRING_MIDDLE:
+			exp.Parts = append(exp.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		case token.STRING_END:
+			exp.Parts = append(exp.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+			return exp
+		default:
+			p.addError(p.curToken, fmt.Sprintf("expected } to end the expression in the string, got %s instead", p.curToken.Type))
+			return nil
+		}
+	}
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
@@ -480,11 +791,30 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	return list
 }
 
+// parseIndexExpression parses left[index] and the slices left[start:end],
+// where either bound may be left out
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	tok := p.curToken
+
+	var index ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		index = p.parseExpression(LOWEST)
+	}
 
+	if !p.peekTokenIs(token.COLON) {
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return &ast.IndexExpression{Token: tok, Left: left, Index: index}
+	}
 	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+
+	exp := &ast.SliceExpression{Token: tok, Left: left, Start: index}
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
 
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
@@ -493,6 +823,17 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
 	hash.Pairs = make(map[ast.Expression]ast.Expression)
@@ -508,6 +849,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -520,3 +862,163 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 	return hash
 }
+
+// parseMatchExpression parses match (subject) { pattern if guard => result, ... }
+// where the guards are optional and a comma may follow the last arm
+func (p *Parser) parseMatchExpression() ast.Expression {
+	exp := &ast.MatchExpression{Token: p.curToken, Arms: []*ast.MatchArm{}}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	exp.Subject = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		exp.Arms = append(exp.Arms, arm)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+	exp.Rbrace = p.curToken
+
+	return exp
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Pattern: p.parsePattern()}
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.FAT_ARROW) {
+		return nil
+	}
+	arm.Token = p.curToken
+	p.nextToken()
+	arm.Result = p.parseExpression(LOWEST)
+
+	return arm
+}
+
+// parsePattern parses the pattern starting at the current token: a literal,
+// _, a name, [patterns, ...rest] or {key: pattern, name}, where a name alone
+// stands for "name": name. Elements of arrays and hashes may end with
+// = default.
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		return &ast.BindingPattern{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE:
+		return &ast.LiteralPattern{Token: p.curToken, Value: p.prefixParseFns[p.curToken.Type]()}
+
+	case token.MINUS:
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			break
+		}
+		return &ast.LiteralPattern{Token: p.curToken, Value: p.parsePrefixExpression()}
+
+	case token.LBRACKET:
+		pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+		for !p.peekTokenIs(token.RBRACKET) {
+			p.nextToken()
+			if p.curTokenIs(token.ELLIPSIS) {
+				p.nextToken()
+				if pattern.Rest = p.parsePattern(); pattern.Rest == nil {
+					return nil
+				}
+				break
+			}
+
+			el := p.parseDefault(p.parsePattern())
+			if el == nil {
+				return nil
+			}
+			pattern.Elements = append(pattern.Elements, el)
+
+			if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+		}
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return pattern
+
+	case token.LBRACE:
+		pattern := &ast.HashPattern{Token: p.curToken, Keys: []ast.Expression{}, Values: []ast.Pattern{}}
+		for !p.peekTokenIs(token.RBRACE) {
+			p.nextToken()
+
+			switch {
+			case p.curTokenIs(token.IDENT) && (p.peekTokenIs(token.COMMA) || p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.ASSIGN)):
+				key := &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+				value := p.parseDefault(&ast.BindingPattern{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}})
+				if value == nil {
+					return nil
+				}
+				pattern.Keys = append(pattern.Keys, key)
+				pattern.Values = append(pattern.Values, value)
+			case p.curTokenIs(token.STRING) || p.curTokenIs(token.INT) || p.curTokenIs(token.TRUE) || p.curTokenIs(token.FALSE):
+				key := p.prefixParseFns[p.curToken.Type]()
+				if !p.expectPeek(token.COLON) {
+					return nil
+				}
+				p.nextToken()
+				value := p.parseDefault(p.parsePattern())
+				if value == nil {
+					return nil
+				}
+				pattern.Keys = append(pattern.Keys, key)
+				pattern.Values = append(pattern.Values, value)
+			default:
+				p.addError(p.curToken, fmt.Sprintf("expected a hash pattern key, got %s instead", p.curToken.Type))
+				return nil
+			}
+
+			if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+		}
+		p.nextToken()
+		return pattern
+	}
+
+	p.addError(p.curToken, fmt.Sprintf("expected a pattern, got %s instead", p.curToken.Type))
+	return nil
+}
+
+// parseDefault parses the `= default` that may follow pattern as an element
+// of an array or hash pattern
+func (p *Parser) parseDefault(pattern ast.Pattern) ast.Pattern {
+	if pattern == nil || !p.peekTokenIs(token.ASSIGN) {
+		return pattern
+	}
+	p.nextToken()
+	dp := &ast.DefaultPattern{Token: p.curToken, Pattern: pattern}
+	p.nextToken()
+	if dp.Default = p.parseExpression(LOWEST); dp.Default == nil {
+		return nil
+	}
+	return dp
+}
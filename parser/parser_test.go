@@ -108,6 +108,22 @@ func TestIdentifierExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	l := lexer.New("2.25;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 2.25 {
+		t.Errorf("literal.Value not %f. got=%f", 2.25, literal.Value)
+	}
+}
+
 func TestIntegerLiteralExpression(t *testing.T) {
 	input := "5;"
 
@@ -139,6 +155,26 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	input := "9223372036854775808;"
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Big == nil || literal.Big.String() != "9223372036854775808" {
+		t.Errorf("literal.Big not %s. got=%v", "9223372036854775808", literal.Big)
+	}
+	if literal.Value != 0 {
+		t.Errorf("literal.Value not 0. got=%d", literal.Value)
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -349,6 +385,18 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a + b in c == !d",
+			"(((a + b) in c) == (!d))",
+		},
+		{
+			"-a.b.c(1) * d[0].e",
+			"((-((a.b).c)(1)) * ((d[0]).e))",
+		},
+		{
+			"a[1:b + 1][:-1][2:][:]",
+			"((((a[1:(b + 1)])[:(-1)])[2:])[:])",
+		},
 	}
 
 	for _, tt := range tests {
@@ -944,3 +992,322 @@ func TestParsingEmptyHashLiteral(t *testing.T) {
 		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 }
+
+func TestTypeAnnotationParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let xs: [string] = [];", "let xs: [string] = [];"},
+		{"let h: {string: [int]} = {};", "let h: {string: [int]} = {};"},
+		{"let f: fn(int, string) -> bool = g;", "let f: fn(int, string) -> bool = g;"},
+		{"let f: fn() -> fn(int) -> int = g;", "let f: fn() -> fn(int) -> int = g;"},
+		{"fn(a: int, b) -> bool { a }", "fn(a: int, b) -> bool a"},
+		{"fn(a, b) { a }", "fn(a, b) a"},
+		{"fn(f: fn(any) -> null) -> {int: float} { f }", "fn(f: fn(any) -> null) -> {int: float} f"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("fn(a, b: int) { a }"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(fn.ParameterTypes) != 2 || fn.ParameterType(0) != nil || fn.ParameterType(1).String() != "int" {
+		t.Errorf("wrong parameter types. got=%v", fn.ParameterTypes)
+	}
+}
+
+func TestTypeAnnotationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: = 5;", "expected a type, got = instead"},
+		{"let x: [int = 5;", "expected next token to be ], got = instead"},
+		{"let f: fn(int) = g;", "expected next token to be ->, got = instead"},
+		{"fn(a: 1) { a }", "expected a type, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { 1 => a, _ => b }", "match (x) { 1 => a, _ => b }"},
+		{"match (x) { -1 => a, 2.5 => b, \"s\" => c, true => d, }", "match (x) { (-1) => a, 2.5 => b, s => c, true => d }"},
+		{"match (xs) { [] => 0, [head, ...tail] => head }", "match (xs) { [] => 0, [head, ...tail] => head }"},
+		{"match (xs) { [_, [a]] => a }", "match (xs) { [_, [a]] => a }"},
+		{`match (h) { {"name": n, age} => n }`, "match (h) { {name: n, age: age} => n }"},
+		{"match (n) { x if x > 1 => x * 2, _ => 0 }", "match (n) { x if (x > 1) => (x * 2), _ => 0 }"},
+		{"match (x) {}", "match (x) {  }"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestMatchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { + => 1 }", "expected a pattern, got + instead"},
+		{"match (x) { [a, ...] => 1 }", "expected a pattern, got ] instead"},
+		{"match (x) { {[1]: a} => 1 }", "expected a hash pattern key, got [ instead"},
+		{"match (x) { a 1 }", "expected next token to be =>, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestDestructuringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = pair;", "let [a, b] = pair;"},
+		{"let {name, age} = person;", "let {name: name, age: age} = person;"},
+		{`let {"pos": [x, y], tags = []} = p;`, "let {pos: [x, y], tags: tags = []} = p;"},
+		{"let [a, b = 1 + 1, ...rest]: [int] = xs;", "let [a, b = (1 + 1), ...rest]: [int] = xs;"},
+		{"fn([a, b], {c}) { a }", "fn([a, b], {c: c}) a"},
+		{"fn(x, [a = 1]: [int]) { a }", "fn(x, [a = 1]: [int]) a"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("fn(x, [a, b]) { a }"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(fn.Parameters) != 2 || fn.ParameterPattern(0) != nil || fn.ParameterPattern(1).String() != "[a, b]" {
+		t.Errorf("wrong parameter patterns. got=%v", fn.ParameterPatterns)
+	}
+	if fn.Parameters[1].Value != "[a, b]" {
+		t.Errorf("wrong name for the pattern parameter. got=%q", fn.Parameters[1].Value)
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, 1 +] = x;", "expected next token to be ,, got + instead"},
+		{"let [a = ] = x;", "no prefix parse function for ] found"},
+		{"let {a b} = x;", "expected a hash pattern key, got IDENT instead"},
+		{"fn([a, ...]) { a }", "expected a pattern, got ] instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestParameterAndArgumentParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(x, y = 10) { x }", "fn(x, y = 10) x"},
+		{"fn(x: int = 1 + 2, ...rest: [int]) { x }", "fn(x: int = (1 + 2), ...rest: [int]) x"},
+		{"fn(...all) { all }", "fn(...all) all"},
+		{"fn([a, b] = [1, 2]) { a }", "fn([a, b] = [1, 2]) a"},
+		{"f(...xs)", "f(...xs)"},
+		{"f(1, ...xs, 2)", "f(1, ...xs, 2)"},
+		{"f(1, y: 2 * 3, z: g(a: 1))", "f(1, y: (2 * 3), z: g(a: 1))"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("fn(a, b = 1, ...c) { a }"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if !fn.Variadic || fn.Required() != 1 || fn.ParameterDefault(0) != nil || fn.ParameterDefault(1).String() != "1" {
+		t.Errorf("wrong parameters. got variadic=%t required=%d defaults=%v", fn.Variadic, fn.Required(), fn.ParameterDefaults)
+	}
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(...a, b) { a }", "the rest parameter must be the last parameter"},
+		{"fn(...a = []) { a }", "the rest parameter cannot have a default"},
+		{"fn(a = 1, b) { a }", "parameter b needs a default, as it follows one with a default"},
+		{"fn(...) { 1 }", "expected next token to be IDENT, got ) instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestInterpolatedStringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		parts    int
+	}{
+		{`"hello ${name}!"`, `"hello ${name}!"`, 3},
+		{`"${a + 1}${b}"`, `"${(a + 1)}${b}"`, 5},
+		{`"outer ${"inner ${x}"}"`, `"outer ${"inner ${x}"}"`, 3},
+		{`"${ {"a": 1}["a"] }"`, `"${({a:1}[a])}"`, 3},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+		str, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.InterpolatedString)
+		if !ok || len(str.Parts) != tt.parts {
+			t.Errorf("%s: expected an interpolated string of %d parts. got=%#v", tt.input, tt.parts, str)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a ${} b"`, "expected an expression inside ${}"},
+		{`"a ${x y} b"`, "expected } to end the expression in the string, got IDENT instead"},
+		{`"a ${x`, "expected } to end the expression in the string, got EOF instead"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestForExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (x in xs) { puts(x) }", "for (x in xs) puts(x)"},
+		{"for ([k, v] in items(h)) { k; v }", "for ([k, v] in items(h)) kv"},
+		{"for (_ in range(1 + 2)) {}", "for (_ in range((1 + 2))) "},
+		{"let f = fn() { for (x in xs) { yield x * 2; } }", "let f = fn() for (x in xs) yield (x * 2);;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"for x in xs {}", "expected next token to be (, got IDENT instead"},
+		{"for (x of xs) {}", "expected next token to be IN, got IDENT instead"},
+		{"for (+ in xs) {}", "expected a pattern, got + instead"},
+		{"for (x in xs) x", "expected next token to be {, got IDENT instead"},
+		{"yield 1;", "yield outside a function"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestGeneratorFunctions(t *testing.T) {
+	input := `fn() { if (a) { yield 1 }; fn() { 2 } }; fn() { fn() { yield 3 } }`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	generators := []bool{}
+	ast.Inspect(program, func(n ast.Node) bool {
+		if fn, ok := n.(*ast.FunctionLiteral); ok {
+			generators = append(generators, fn.Generator)
+		}
+		return true
+	})
+
+	expected := []bool{true, false, false, true}
+	if fmt.Sprint(generators) != fmt.Sprint(expected) {
+		t.Errorf("wrong generator flags. expected=%v, got=%v", expected, generators)
+	}
+}
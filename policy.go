@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"monkey_interpreter/evaluator"
+	"strings"
+)
+
+// policyFlags registers the -allow-* flags on flags and returns a function that
+// builds the Policy they describe once flags have been parsed
+func policyFlags(flags *flag.FlagSet) func() evaluator.Policy {
+	read := flags.String("allow-read", "", "comma separated files and directories scripts may read, * for any")
+	write := flags.String("allow-write", "", "comma separated files and directories scripts may write, * for any")
+	env := flags.String("allow-env", "", "comma separated environment variables scripts may read, * for any")
+	clock := flags.Bool("allow-clock", false, "let scripts read the current time")
+	random := flags.Bool("allow-random", false, "let scripts draw random numbers")
+	output := flags.Bool("allow-output", true, "let scripts print")
+	all := flags.Bool("allow-all", false, "grant every capability")
+
+	return func() evaluator.Policy {
+		if *all {
+			return evaluator.AllowAll
+		}
+		return evaluator.Policy{
+			Read:   splitList(*read),
+			Write:  splitList(*write),
+			Env:    splitList(*env),
+			Clock:  *clock,
+			Random: *random,
+			Output: *output,
+		}
+	}
+}
+
+// splitList splits a comma separated flag value, dropping empty entries
+func splitList(value string) []string {
+	list := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
@@ -0,0 +1,191 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"monkey_interpreter/debugger"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/parser"
+	"strconv"
+	"strings"
+)
+
+// DEBUG_PROMPT is shown while a program is paused
+const DEBUG_PROMPT = "(debug) "
+
+const debugHelp = `commands:
+  break N | b N       set a breakpoint on line N
+  clear N             remove the breakpoint on line N
+  breakpoints         list breakpoints
+  continue | c        run to the next breakpoint
+  step | s            step to the next statement, entering calls
+  next | n            step over calls to the next statement
+  out | o             run until the current function returns
+  stack | bt          print the call stack
+  frame N | f N       select stack frame N for env, print and set
+  env                 print the environment chain of the selected frame
+  print EXPR | p EXPR evaluate EXPR in the selected frame
+  set NAME = EXPR     rebind NAME where it is defined
+  list | l            show the source around the current line
+  quit | q            abort the program
+`
+
+// session is the state of the terminal debugger while a program is paused
+type session struct {
+	out      io.Writer
+	d        *debugger.Debugger
+	lines    []string
+	stop     debugger.Stop
+	selected *object.Environment
+}
+
+// Debug runs source under the debugger with policy, reading commands from in whenever it pauses
+func Debug(in io.Reader, out io.Writer, source string, policy evaluator.Policy) {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, p.Errors())
+		return
+	}
+
+	scanner := bufio.NewScanner(in)
+	s := &session{out: out, lines: strings.Split(source, "\n")}
+	s.d = debugger.New(func(stop debugger.Stop) debugger.Action {
+		s.stop, s.selected = stop, stop.Env
+		fmt.Fprintf(out, "stopped at line %d (%s)\n", stop.Line, stop.Reason)
+		s.list(stop.Line, 0)
+
+		for {
+			io.WriteString(out, DEBUG_PROMPT)
+			if !scanner.Scan() {
+				return debugger.Quit
+			}
+			if action, resume := s.command(scanner.Text()); resume {
+				return action
+			}
+		}
+	})
+	s.d.StopOnEntry = true
+	s.d.Interpreter.Policy = policy
+	s.d.Interpreter.Out = out
+
+	evaluated := s.d.Run(program, object.NewEnvironment())
+	io.WriteString(out, "program finished")
+	if evaluated != nil {
+		io.WriteString(out, ": "+evaluated.Inspect())
+	}
+	io.WriteString(out, "\n")
+}
+
+// command runs a debugger command, reporting whether the program should resume
+func (s *session) command(line string) (debugger.Action, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+
+	switch fields[0] {
+	case "continue", "c":
+		return debugger.Continue, true
+	case "step", "s":
+		return debugger.StepIn, true
+	case "next", "n":
+		return debugger.StepOver, true
+	case "out", "o":
+		return debugger.StepOut, true
+	case "quit", "q":
+		return debugger.Quit, true
+
+	case "break", "b", "clear":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			fmt.Fprintf(s.out, "%s needs a line number\n", fields[0])
+			break
+		}
+		if fields[0] == "clear" {
+			s.d.ClearBreakpoint(n)
+		} else {
+			s.d.SetBreakpoint(n)
+			fmt.Fprintf(s.out, "breakpoint set on line %d\n", n)
+		}
+	case "breakpoints":
+		for _, n := range s.d.Breakpoints() {
+			fmt.Fprintf(s.out, "line %d\n", n)
+		}
+
+	case "stack", "bt":
+		for i, frame := range s.d.Stack() {
+			fmt.Fprintf(s.out, "#%d %s at line %d\n", i, frame.Name, frame.Line)
+		}
+	case "frame", "f":
+		stack := s.d.Stack()
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 || n >= len(stack) {
+			fmt.Fprintf(s.out, "frame needs a number between 0 and %d\n", len(stack)-1)
+			break
+		}
+		s.selected = stack[n].Env
+		fmt.Fprintf(s.out, "#%d %s at line %d\n", n, stack[n].Name, stack[n].Line)
+	case "env":
+		for depth, env := 0, s.selected; env != nil; depth, env = depth+1, env.Outer() {
+			fmt.Fprintf(s.out, "scope %d:\n", depth)
+			for _, name := range env.Names() {
+				val, _ := env.Get(name)
+				fmt.Fprintf(s.out, "  %s = %s\n", name, debugger.Summarize(val))
+			}
+		}
+
+	case "print", "p":
+		evaluated, errors := debugger.Evaluate(arg, s.selected)
+		if len(errors) != 0 {
+			printParserErrors(s.out, errors)
+		} else if evaluated != nil {
+			io.WriteString(s.out, evaluated.Inspect()+"\n")
+		}
+	case "set":
+		parts := strings.SplitN(arg, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			io.WriteString(s.out, "usage: set NAME = EXPR\n")
+			break
+		}
+		evaluated, errors := debugger.Evaluate(parts[1], s.selected)
+		switch {
+		case len(errors) != 0:
+			printParserErrors(s.out, errors)
+		case evaluated == nil || evaluated.Type() == object.ERROR_OBJ:
+			fmt.Fprintf(s.out, "cannot set %s: %s\n", name, debugger.Summarize(evaluated))
+		case !s.selected.Assign(name, evaluated):
+			fmt.Fprintf(s.out, "%s is not defined\n", name)
+		default:
+			fmt.Fprintf(s.out, "%s = %s\n", name, debugger.Summarize(evaluated))
+		}
+
+	case "list", "l":
+		s.list(s.stop.Line, 3)
+	case "help", "h":
+		io.WriteString(s.out, debugHelp)
+	default:
+		fmt.Fprintf(s.out, "unknown command %q, try help\n", fields[0])
+	}
+
+	return 0, false
+}
+
+// list prints the source lines within context of line, marking line itself
+func (s *session) list(line, context int) {
+	for n := line - context; n <= line+context; n++ {
+		if n < 1 || n > len(s.lines) {
+			continue
+		}
+		marker := "  "
+		if n == line {
+			marker = "=>"
+		}
+		fmt.Fprintf(s.out, "%s %4d | %s\n", marker, n, s.lines[n-1])
+	}
+}
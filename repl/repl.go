@@ -2,21 +2,30 @@ package repl
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"monkey_interpreter/ast"
 	"monkey_interpreter/evaluator"
 	"monkey_interpreter/lexer"
 	"monkey_interpreter/object"
 	"monkey_interpreter/parser"
+	"monkey_interpreter/resolver"
+	"os"
+	"os/signal"
 )
 
 // PROMPT for input
 const PROMPT = ">> "
 
-// Start function starts our RPPL
-func Start(in io.Reader, out io.Writer) {
+// Start function starts our RPPL, running input under policy
+func Start(in io.Reader, out io.Writer, policy evaluator.Policy) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	interpreter := evaluator.New()
+	defer interpreter.Close()
+	interpreter.Policy = policy
+	interpreter.Out = out
 
 	for {
 		fmt.Printf(PROMPT)
@@ -34,8 +43,9 @@ func Start(in io.Reader, out io.Writer) {
 			printParserErrors(out, p.Errors())
 			continue
 		}
+		resolver.Resolve(program)
 
-		evaluated := evaluator.Eval(program, env)
+		evaluated := evalInterruptibly(interpreter, program, env)
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
@@ -43,6 +53,26 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 
+// evalInterruptibly evaluates program, aborting it rather than the session on Ctrl-C
+func evalInterruptibly(interpreter *evaluator.Interpreter, program *ast.Program, env *object.Environment) object.Object {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return interpreter.EvalContext(ctx, program, env)
+}
+
 // MONKEY_FACE is used for error output
 const MONKEY_FACE = `            __,__
    .--.  .-"     "-.  .--.
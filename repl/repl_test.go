@@ -0,0 +1,85 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"monkey_interpreter/evaluator"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartResumesGeneratorsAcrossLines(t *testing.T) {
+	input := strings.Join([]string{
+		`let count = fn() { yield 1; yield 2; yield 3 };`,
+		`let it = count();`,
+		`take(it, 1)`,
+		`take(it, 1)`,
+		`collect(it)`,
+	}, "\n")
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out, evaluator.Policy{})
+
+	if got, want := out.String(), "[1]\n[2]\n[3]\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("wrong output. expected suffix %q, got %q", want, got)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe to read while Start writes to it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStartInterruptsTheRunningLine(t *testing.T) {
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// keep interrupts sent while no line is running from stopping the test
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, os.Interrupt)
+	defer signal.Stop(ignored)
+
+	input, lines := io.Pipe()
+	out := &lockedBuffer{}
+	done := make(chan struct{})
+	go func() {
+		Start(input, out, evaluator.Policy{})
+		close(done)
+	}()
+
+	io.WriteString(lines, "for (x in range(1000000000000000000)) { x }\n")
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(out.String(), "evaluation canceled"); {
+		if time.Now().After(deadline) {
+			t.Fatalf("the running line was not interrupted. output=%q", out.String())
+		}
+		if err := self.Signal(os.Interrupt); err != nil {
+			t.Skipf("cannot send interrupts: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	io.WriteString(lines, "1 + 1\n")
+	lines.Close()
+	<-done
+	if got, want := out.String(), "evaluation canceled\n2\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("wrong output. expected suffix %q, got %q", want, got)
+	}
+}
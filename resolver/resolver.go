@@ -0,0 +1,126 @@
+package resolver
+
+import "monkey_interpreter/ast"
+
+// Resolve assigns every identifier in program a place to find its binding at
+// run time, so the evaluator can skip searching environments by name.
+//
+// Each function call gets one environment with a slot for every parameter and
+// every name its parameters and body bind with let or a pattern, outside of
+// nested functions and match arms. Each match arm tried gets one too, enclosed
+// by the environment of the match, for the names its pattern binds and the
+// lets in its guard and result. Identifiers bound that way become Local with
+// the number of environments out (Depth) and the slot (Index). Anything else
+// is global: looked up by name in the environment Depth environments out,
+// which is the one program runs in.
+//
+// Resolve must only be used on programs evaluated in a global environment, and
+// running it again on the same program is harmless.
+func Resolve(program *ast.Program) {
+	resolve(program, nil)
+}
+
+// scope is a function or match arm being resolved
+type scope struct {
+	locals *[]string // the Locals of the function or arm
+	slots  map[string]int
+	outer  *scope
+}
+
+// lookup finds the slot for name in s or an enclosing scope
+func (s *scope) lookup(name string) (depth, index int, ok bool) {
+	for ; s != nil; s, depth = s.outer, depth+1 {
+		if index, ok := s.slots[name]; ok {
+			return depth, index, true
+		}
+	}
+	return depth, 0, false
+}
+
+func (s *scope) declare(name string) {
+	if _, ok := s.slots[name]; !ok {
+		s.slots[name] = len(*s.locals)
+		*s.locals = append(*s.locals, name)
+	}
+}
+
+func resolve(node ast.Node, s *scope) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Identifier:
+			n.Depth, n.Index, n.Local = s.lookup(n.Value)
+		case *ast.FunctionLiteral:
+			resolveFunction(n, s)
+			return false
+		case *ast.MatchArm:
+			resolveArm(n, s)
+			return false
+		}
+		return true
+	})
+}
+
+// declare declares the names node binds with let or a pattern, outside of
+// nested functions and match arms, which have scopes of their own
+func declare(node ast.Node, s *scope) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			if n.Name != nil {
+				s.declare(n.Name.Value)
+			}
+		case *ast.BindingPattern:
+			s.declare(n.Name.Value)
+		case *ast.FunctionLiteral, *ast.MatchArm:
+			return false
+		}
+		return true
+	})
+}
+
+func resolveFunction(fn *ast.FunctionLiteral, outer *scope) {
+	s := &scope{locals: &fn.Locals, slots: make(map[string]int), outer: outer}
+	fn.Locals = nil
+
+	for i, param := range fn.Parameters {
+		s.declare(param.Value)
+		if pattern := fn.ParameterPattern(i); pattern != nil {
+			for _, name := range ast.PatternNames(pattern) {
+				s.declare(name.Value)
+			}
+		}
+	}
+	if fn.Body == nil {
+		return
+	}
+	// bindings are declared up front so closures created before a let see its slot
+	declare(fn.Body, s)
+
+	for i, param := range fn.Parameters {
+		resolve(param, s)
+		if pattern := fn.ParameterPattern(i); pattern != nil {
+			resolve(pattern, s)
+		}
+		if d := fn.ParameterDefault(i); d != nil {
+			resolve(d, s)
+		}
+	}
+	resolve(fn.Body, s)
+}
+
+func resolveArm(arm *ast.MatchArm, outer *scope) {
+	s := &scope{locals: &arm.Locals, slots: make(map[string]int), outer: outer}
+	arm.Locals = nil
+
+	parts := []ast.Node{arm.Pattern}
+	if arm.Guard != nil {
+		parts = append(parts, arm.Guard)
+	}
+	parts = append(parts, arm.Result)
+	for _, part := range parts {
+		declare(part, s)
+	}
+	for _, part := range parts {
+		resolve(part, s)
+	}
+}
@@ -0,0 +1,121 @@
+package resolver
+
+import (
+	"monkey_interpreter/ast"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/parser"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	input := `let g = 1;
+let f = fn(a, b) {
+  let c = a + g;
+  if (b) { let d = c; d };
+  fn(e) { a + e + later + g };
+  let later = 2;
+};`
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	Resolve(program)
+
+	type place struct {
+		local        bool
+		depth, index int
+	}
+	got := map[string][]place{}
+	var outer, inner *ast.FunctionLiteral
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Identifier:
+			got[n.Value] = append(got[n.Value], place{n.Local, n.Depth, n.Index})
+		case *ast.FunctionLiteral:
+			if outer == nil {
+				outer = n
+			} else {
+				inner = n
+			}
+		}
+		return true
+	})
+
+	expected := map[string][]place{
+		"g":     {{false, 0, 0}, {false, 1, 0}, {false, 2, 0}},
+		"f":     {{false, 0, 0}},
+		"a":     {{true, 0, 0}, {true, 0, 0}, {true, 1, 0}},
+		"b":     {{true, 0, 1}, {true, 0, 1}},
+		"c":     {{true, 0, 2}, {true, 0, 2}},
+		"d":     {{true, 0, 3}, {true, 0, 3}},
+		"e":     {{true, 0, 0}, {true, 0, 0}},
+		"later": {{true, 1, 4}, {true, 0, 4}},
+	}
+	for name, places := range expected {
+		if len(got[name]) != len(places) {
+			t.Errorf("%s: expected %d uses. got=%+v", name, len(places), got[name])
+			continue
+		}
+		for i, want := range places {
+			if got[name][i] != want {
+				t.Errorf("%s use %d: expected %+v. got=%+v", name, i, want, got[name][i])
+			}
+		}
+	}
+
+	if len(outer.Locals) != 5 || outer.Locals[4] != "later" || len(inner.Locals) != 1 {
+		t.Errorf("wrong locals. got=%v and %v", outer.Locals, inner.Locals)
+	}
+}
+
+func TestResolveMatchArms(t *testing.T) {
+	input := `let f = fn(x) {
+  match (x) { [x, y] if y => x + g, _ => x }
+};`
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	Resolve(program)
+
+	type place struct {
+		local        bool
+		depth, index int
+	}
+	got := map[string][]place{}
+	var arms []*ast.MatchArm
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Identifier:
+			got[n.Value] = append(got[n.Value], place{n.Local, n.Depth, n.Index})
+		case *ast.MatchArm:
+			arms = append(arms, n)
+		}
+		return true
+	})
+
+	expected := map[string][]place{
+		"x": {{true, 0, 0}, {true, 0, 0}, {true, 0, 0}, {true, 0, 0}, {true, 1, 0}},
+		"y": {{true, 0, 1}, {true, 0, 1}},
+		"g": {{false, 2, 0}},
+	}
+	for name, places := range expected {
+		if len(got[name]) != len(places) {
+			t.Errorf("%s: expected %d uses. got=%+v", name, len(places), got[name])
+			continue
+		}
+		for i, want := range places {
+			if got[name][i] != want {
+				t.Errorf("%s use %d: expected %+v. got=%+v", name, i, want, got[name][i])
+			}
+		}
+	}
+
+	if len(arms) != 2 || len(arms[0].Locals) != 2 || len(arms[1].Locals) != 0 {
+		t.Errorf("wrong arm locals. got=%v", arms)
+	}
+}
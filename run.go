@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/format"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/optimizer"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/resolver"
+	"monkey_interpreter/typecheck"
+	"os"
+	"os/signal"
+	"path/filepath"
+)
+
+// runFile implements the run subcommand and returns the process exit code
+func runFile(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	var limits evaluator.Limits
+	flags.IntVar(&limits.MaxSteps, "max-steps", 0, "abort after evaluating this many nodes (0 for no limit)")
+	flags.IntVar(&limits.MaxDepth, "max-depth", evaluator.DefaultMaxDepth, "abort when this many calls are in progress")
+	flags.IntVar(&limits.MaxElements, "max-elements", 0, "abort after allocating this many array elements, hash pairs and string bytes (0 for no limit)")
+	flags.DurationVar(&limits.Timeout, "timeout", 0, "abort after running this long, e.g. 5s (0 for no limit)")
+	searchPath := flags.String("path", os.Getenv("MONKEYPATH"), "directories searched for imports, separated by "+string(os.PathListSeparator)+" (defaults to $MONKEYPATH)")
+	optimize := flags.Bool("optimize", false, "fold constants, prune dead branches and inline small functions before running")
+	dump := flags.Bool("dump-optimized", false, "print the optimized program instead of running it")
+	check := flags.Bool("check", false, "type check the program first and do not run it if that fails")
+	policy := policyFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey run [flags] file\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if limits.MaxDepth <= 0 {
+		fmt.Fprintln(os.Stderr, "-max-depth must be positive")
+		return 2
+	}
+
+	source, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "%s: parser error: %s\n", flags.Arg(0), msg)
+		}
+		return 1
+	}
+	if *check {
+		if errors := typecheck.Check(program); len(errors) != 0 {
+			for _, e := range errors {
+				fmt.Fprintf(os.Stderr, "%s:%s\n", flags.Arg(0), e)
+			}
+			return 1
+		}
+	}
+	if *optimize || *dump {
+		optimizer.Optimize(program)
+	}
+	if *dump {
+		fmt.Println(format.Node(program, format.Default))
+		return 0
+	}
+	resolver.Resolve(program)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	interpreter := evaluator.New()
+	defer interpreter.Close()
+	interpreter.Limits = limits
+	interpreter.Policy = policy()
+	interpreter.File = flags.Arg(0)
+	interpreter.SearchPath = filepath.SplitList(*searchPath)
+	evaluated := interpreter.EvalContext(ctx, program, object.NewEnvironment())
+	if err, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, err.Inspect())
+		return 1
+	}
+	if evaluated != nil && evaluated != evaluator.NULL {
+		fmt.Println(evaluated.Inspect())
+	}
+	return 0
+}
@@ -7,6 +7,8 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int // 1-based line the token starts on
+	Column  int // 1-based column the token starts on
 }
 
 const (
@@ -17,8 +19,17 @@ const (
 
 	IDENT  = "IDENT" // add, foobar, x, y, ...
 	INT    = "INT"   // 1343456
+	FLOAT  = "FLOAT" // 3.14
 	STRING = "STRING"
 
+	// An interpolated string "a${x}b${y}c" is lexed as STRING_START "a", the
+	// tokens of x, STRING_MIDDLE "b", the tokens of y and STRING_END "c"
+	STRING_START  = "STRING_START"
+	STRING_MIDDLE = "STRING_MIDDLE"
+	STRING_END    = "STRING_END"
+
+	COMMENT = "COMMENT" // a // comment, kept aside by the lexer rather than emitted
+
 	// Operators
 
 	ASSIGN   = "="
@@ -34,6 +45,10 @@ const (
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	ARROW     = "->"  // between the parameters and result of a function type
+	FAT_ARROW = "=>"  // between the pattern and result of a match arm
+	ELLIPSIS  = "..." // before the rest of an array pattern
+
 	// Delimiters
 
 	COMMA     = ","
@@ -45,6 +60,7 @@ const (
 	LBRACKET  = "["
 	RBRACKET  = "]"
 	COLON     = ":"
+	DOT       = "."
 
 	// Keywords
 
@@ -55,6 +71,10 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	IN       = "IN"
+	MATCH    = "MATCH"
+	FOR      = "FOR"
+	YIELD    = "YIELD"
 )
 
 var keywords = map[string]TokenType{
@@ -65,6 +85,10 @@ var keywords = map[string]TokenType{
 	"if":     IF,
 	"else":   ELSE,
 	"return": RETURN,
+	"in":     IN,
+	"match":  MATCH,
+	"for":    FOR,
+	"yield":  YIELD,
 }
 
 // LookupIdent checks to see if a given string represents a keyword or is meant as a var name
@@ -0,0 +1,92 @@
+package typecheck
+
+// builtinTypes makes the types of the builtin functions that have one. The
+// others, like puts, which takes any number of arguments, have type any.
+var builtinTypes = map[string]func(c *checker) Type{
+	"len": func(c *checker) Type {
+		return funcType(intType, anyType)
+	},
+	"first": func(c *checker) Type {
+		a := c.fresh(anyKind)
+		return funcType(a, &arrayType{element: a})
+	},
+	"last": func(c *checker) Type {
+		a := c.fresh(anyKind)
+		return funcType(a, &arrayType{element: a})
+	},
+	"rest": func(c *checker) Type {
+		a := c.fresh(anyKind)
+		return funcType(&arrayType{element: a}, &arrayType{element: a})
+	},
+	"push": func(c *checker) Type {
+		a := c.fresh(anyKind)
+		return funcType(&arrayType{element: a}, &arrayType{element: a}, a)
+	},
+	"map": func(c *checker) Type {
+		a, b := c.fresh(anyKind), c.fresh(anyKind)
+		return funcType(&arrayType{element: b}, &arrayType{element: a}, funcType(b, a))
+	},
+	"filter": func(c *checker) Type {
+		a := c.fresh(anyKind)
+		return funcType(&arrayType{element: a}, &arrayType{element: a}, funcType(anyType, a))
+	},
+	"reduce": func(c *checker) Type {
+		a, b := c.fresh(anyKind), c.fresh(anyKind)
+		return funcType(b, &arrayType{element: a}, b, funcType(b, b, a))
+	},
+	"keys": func(c *checker) Type {
+		k, v := c.fresh(anyKind), c.fresh(anyKind)
+		return funcType(&arrayType{element: k}, &hashType{key: k, value: v})
+	},
+	"values": func(c *checker) Type {
+		k, v := c.fresh(anyKind), c.fresh(anyKind)
+		return funcType(&arrayType{element: v}, &hashType{key: k, value: v})
+	},
+	"split": func(c *checker) Type {
+		return funcType(&arrayType{element: stringType}, stringType, stringType)
+	},
+	"join": func(c *checker) Type {
+		return funcType(stringType, &arrayType{element: anyType}, stringType)
+	},
+	"trim":       stringFunction(1, stringType),
+	"upper":      stringFunction(1, stringType),
+	"lower":      stringFunction(1, stringType),
+	"contains":   stringFunction(2, boolType),
+	"startsWith": stringFunction(2, boolType),
+	"endsWith":   stringFunction(2, boolType),
+	"replace":    stringFunction(3, stringType),
+	"chars": func(c *checker) Type {
+		return funcType(&arrayType{element: stringType}, stringType)
+	},
+	"repeat": func(c *checker) Type {
+		return funcType(stringType, stringType, intType)
+	},
+	"readFile": stringFunction(1, stringType),
+	"readLines": func(c *checker) Type {
+		return funcType(&arrayType{element: stringType}, stringType)
+	},
+	"writeFile":  stringFunction(2, nullType),
+	"appendFile": stringFunction(2, nullType),
+	"exists":     stringFunction(1, boolType),
+}
+
+// functionFirstBuiltins take their function first or second. Their types
+// have it second, and calls with it first are checked against the type with
+// its parameters swapped.
+var functionFirstBuiltins = map[string]bool{"map": true, "filter": true}
+
+// funcType returns the type of functions from params to result
+func funcType(result Type, params ...Type) *functionType {
+	return &functionType{params: params, result: result}
+}
+
+// stringFunction makes the type of builtins taking n strings
+func stringFunction(n int, result Type) func(c *checker) Type {
+	return func(c *checker) Type {
+		params := make([]Type, n)
+		for i := range params {
+			params[i] = stringType
+		}
+		return funcType(result, params...)
+	}
+}
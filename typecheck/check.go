@@ -0,0 +1,678 @@
+package typecheck
+
+import (
+	"fmt"
+	"monkey_interpreter/ast"
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/parser"
+	"monkey_interpreter/token"
+	"sort"
+)
+
+// Error is a type error found before the program runs
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e Error) String() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// binding is the type of a name introduced by a let or a parameter. Names a
+// scope binds with let are declared before its statements are checked, since
+// functions may refer to lets that come after them, and are defined once
+// their let is reached.
+type binding struct {
+	scheme  *scheme
+	defined bool
+}
+
+// scope is a program or function body. Blocks do not open one because the
+// evaluator runs them in the enclosing environment.
+type scope struct {
+	names map[string]*binding
+	outer *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{names: make(map[string]*binding), outer: outer}
+}
+
+func (s *scope) lookup(name string) (*binding, bool) {
+	for ; s != nil; s = s.outer {
+		if b, ok := s.names[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+type checker struct {
+	errors    []Error
+	level     int
+	vars      int // the number of variables made
+	trail     []trailEntry
+	kindError kind   // the kind a failed unification could not satisfy, if any
+	results   []Type // the result types of the functions being checked, innermost last
+}
+
+// Check infers the type of everything in program, Hindley-Milner style, and
+// returns the errors it finds ordered by position. Annotations fix the types
+// of lets, parameters and results; everything else is inferred from how it is
+// used, and functions bound by let are generic. Values the checker cannot
+// follow, such as those of modules, arrays and hashes mixing types, and if
+// without else, have type any, which is accepted everywhere.
+func Check(program *ast.Program) []Error {
+	c := &checker{}
+	s := newScope(nil)
+	c.declare(program.Statements, s)
+	c.statements(program.Statements, s)
+
+	sort.SliceStable(c.errors, func(i, j int) bool {
+		a, b := c.errors[i], c.errors[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.errors
+}
+
+// Source parses input and checks it. Parser errors are returned instead of
+// type errors when the input does not parse.
+func Source(input string) ([]Error, []string) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, p.Errors()
+	}
+	return Check(program), nil
+}
+
+func (c *checker) report(tok token.Token, format string, a ...interface{}) {
+	c.errors = append(c.errors, Error{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, a...)})
+}
+
+// expect reports an error unless got can be used where want is expected,
+// which for a float includes an int
+func (c *checker) expect(got, want Type, tok token.Token, context string) {
+	if prune(got) == intType && prune(want) == floatType {
+		return
+	}
+	c.kindError = anyKind
+	if c.unify(got, want) {
+		return
+	}
+	if c.kindError != anyKind {
+		c.report(tok, "%s: expected %s, got %s", context, c.kindError, prune(got))
+	} else {
+		c.report(tok, "%s: expected %s, got %s", context, prune(want), prune(got))
+	}
+}
+
+// join returns a type both a and b fit, which is any when they differ
+func (c *checker) join(a, b Type) Type {
+	if c.unify(a, b) {
+		return a
+	}
+	if isNumber(prune(a)) && isNumber(prune(b)) {
+		return floatType
+	}
+	return anyType
+}
+
+// declare adds the names stmts bind with let or a pattern, outside of
+// functions, to s
+func (c *checker) declare(stmts []ast.Statement, s *scope) {
+	c.level++
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.LetStatement:
+				if n.Name != nil {
+					c.predeclare(n.Name.Value, s)
+				}
+			case *ast.BindingPattern:
+				c.predeclare(n.Name.Value, s)
+			case *ast.FunctionLiteral:
+				return false
+			}
+			return true
+		})
+	}
+	c.level--
+}
+
+func (c *checker) predeclare(name string, s *scope) {
+	if _, ok := s.names[name]; !ok {
+		s.names[name] = &binding{scheme: &scheme{t: c.fresh(anyKind)}}
+	}
+}
+
+// statements checks stmts and returns the type of their value, reporting
+// whether the value is used rather than a return leaving first
+func (c *checker) statements(stmts []ast.Statement, s *scope) (Type, bool) {
+	var t Type = nullType
+	falls := true
+
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			c.let(stmt, s)
+			t = nullType
+		case *ast.ReturnStatement:
+			value := c.expression(stmt.ReturnValue, s)
+			if len(c.results) > 0 && falls {
+				c.expect(value, c.results[len(c.results)-1], ast.TokenOf(stmt.ReturnValue), "return")
+			}
+			falls = false
+		case *ast.YieldStatement:
+			c.expression(stmt.Value, s)
+			t = nullType
+		case *ast.ExpressionStatement:
+			t = c.expression(stmt.Expression, s)
+		case *ast.BlockStatement:
+			var blockFalls bool
+			t, blockFalls = c.statements(stmt.Statements, s)
+			falls = falls && blockFalls
+		}
+	}
+
+	return t, falls
+}
+
+func (c *checker) let(stmt *ast.LetStatement, s *scope) {
+	c.level++
+	t := c.expression(stmt.Value, s)
+	c.level--
+
+	if stmt.Pattern != nil {
+		if stmt.Type != nil {
+			want := c.annotation(stmt.Type)
+			c.expect(t, want, ast.TokenOf(stmt.Value), "let "+stmt.Pattern.String())
+			t = want
+		}
+		c.pattern(stmt.Pattern, t, s)
+		return
+	}
+
+	name := stmt.Name.Value
+	if stmt.Type != nil {
+		want := c.annotation(stmt.Type)
+		c.expect(t, want, ast.TokenOf(stmt.Value), "let "+name)
+		t = want
+	}
+
+	b, ok := s.names[name]
+	if !ok {
+		b = &binding{}
+		s.names[name] = b
+	}
+	if !b.defined {
+		// functions checked before the let may have used the name already
+		c.expect(t, b.scheme.t, stmt.Name.Token, "let "+name)
+	}
+
+	b.defined = true
+	b.scheme = &scheme{t: t}
+	if _, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		b.scheme = c.generalize(t)
+	}
+}
+
+func (c *checker) expression(exp ast.Expression, s *scope) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return intType
+	case *ast.FloatLiteral:
+		return floatType
+	case *ast.StringLiteral:
+		return stringType
+	case *ast.InterpolatedString:
+		for _, part := range exp.Parts {
+			c.expression(part, s)
+		}
+		return stringType
+	case *ast.Boolean:
+		return boolType
+
+	case *ast.Identifier:
+		return c.identifier(exp, s)
+
+	case *ast.PrefixExpression:
+		return c.prefix(exp, c.expression(exp.Right, s))
+
+	case *ast.InfixExpression:
+		return c.infix(exp, c.expression(exp.Left, s), c.expression(exp.Right, s))
+
+	case *ast.IfExpression:
+		c.expression(exp.Condition, s)
+		consequence, consequenceFalls := c.statements(exp.Consequence.Statements, s)
+		if exp.Alternative == nil {
+			return anyType
+		}
+		alternative, alternativeFalls := c.statements(exp.Alternative.Statements, s)
+		switch {
+		case !consequenceFalls:
+			return alternative
+		case !alternativeFalls:
+			return consequence
+		}
+		return c.join(consequence, alternative)
+
+	case *ast.ForExpression:
+		c.pattern(exp.Pattern, c.element(c.expression(exp.Iterable, s)), s)
+		c.statements(exp.Body.Statements, s)
+		return nullType
+
+	case *ast.FunctionLiteral:
+		return c.function(exp, s)
+
+	case *ast.CallExpression:
+		return c.call(exp, s)
+
+	case *ast.ArrayLiteral:
+		if len(exp.Elements) == 0 {
+			return &arrayType{element: c.fresh(anyKind)}
+		}
+		element := c.expression(exp.Elements[0], s)
+		for _, el := range exp.Elements[1:] {
+			element = c.join(element, c.expression(el, s))
+		}
+		return &arrayType{element: element}
+
+	case *ast.HashLiteral:
+		if len(exp.Keys) == 0 {
+			return &hashType{key: c.fresh(anyKind), value: c.fresh(anyKind)}
+		}
+		var key, value Type
+		for i, k := range exp.Keys {
+			kt, vt := c.expression(k, s), c.expression(exp.Pairs[k], s)
+			if i == 0 {
+				key, value = kt, vt
+			} else {
+				key, value = c.join(key, kt), c.join(value, vt)
+			}
+		}
+		return &hashType{key: key, value: value}
+
+	case *ast.IndexExpression:
+		return c.index(exp, c.expression(exp.Left, s), c.expression(exp.Index, s))
+
+	case *ast.SliceExpression:
+		left := prune(c.expression(exp.Left, s))
+		for _, bound := range []ast.Expression{exp.Start, exp.End} {
+			if bound != nil {
+				c.expect(c.expression(bound, s), intType, ast.TokenOf(bound), "slice bound")
+			}
+		}
+		switch left.(type) {
+		case *arrayType, *variable:
+			return left
+		}
+		if left == stringType || left == anyType {
+			return left
+		}
+		c.report(exp.Token, "slice operator not supported: %s", left)
+		return anyType
+
+	case *ast.MemberExpression:
+		obj := prune(c.expression(exp.Object, s))
+		switch obj := obj.(type) {
+		case *hashType:
+			c.expect(stringType, obj.key, exp.Property.Token, "member "+exp.Property.Value)
+			return obj.value
+		case *variable:
+			return anyType
+		}
+		if obj != anyType {
+			c.report(exp.Token, "member access not supported: %s", obj)
+		}
+		return anyType
+
+	case *ast.SpreadExpression:
+		c.expect(c.expression(exp.Value, s), &arrayType{element: c.fresh(anyKind)}, ast.TokenOf(exp.Value), "spread")
+		return anyType
+
+	case *ast.NamedArgument:
+		return c.expression(exp.Value, s)
+
+	case *ast.MatchExpression:
+		subject := c.expression(exp.Subject, s)
+		var result Type = anyType
+		for i, arm := range exp.Arms {
+			c.pattern(arm.Pattern, subject, s)
+			if arm.Guard != nil {
+				c.expression(arm.Guard, s)
+			}
+			if t := c.expression(arm.Result, s); i == 0 {
+				result = t
+			} else {
+				result = c.join(result, t)
+			}
+		}
+		return result
+	}
+
+	return anyType
+}
+
+// pattern binds the names in p to the types of the parts of t they match.
+// Arms may test for values of different types, and a let or parameter that
+// does not fit fails at run time, so nothing is required of t.
+func (c *checker) pattern(p ast.Pattern, t Type, s *scope) {
+	switch p := p.(type) {
+	case *ast.BindingPattern:
+		if b, ok := s.names[p.Name.Value]; ok && !b.defined {
+			// functions checked before the pattern may have used the name already
+			c.expect(t, b.scheme.t, p.Name.Token, p.Name.Value)
+		}
+		s.names[p.Name.Value] = &binding{scheme: &scheme{t: t}, defined: true}
+
+	case *ast.DefaultPattern:
+		c.pattern(p.Pattern, c.join(t, c.expression(p.Default, s)), s)
+
+	case *ast.ArrayPattern:
+		var element Type = anyType
+		if arr, ok := prune(t).(*arrayType); ok {
+			element = arr.element
+		}
+		for _, el := range p.Elements {
+			c.pattern(el, element, s)
+		}
+		if p.Rest != nil {
+			c.pattern(p.Rest, &arrayType{element: element}, s)
+		}
+
+	case *ast.HashPattern:
+		var value Type = anyType
+		if hash, ok := prune(t).(*hashType); ok {
+			value = hash.value
+		}
+		for _, v := range p.Values {
+			c.pattern(v, value, s)
+		}
+	}
+}
+
+// element returns the type of the elements a for loop over a value of type t
+// goes through
+func (c *checker) element(t Type) Type {
+	switch t := prune(t).(type) {
+	case *arrayType:
+		return t.element
+	case *hashType:
+		return t.key
+	case basic:
+		if t == stringType {
+			return stringType
+		}
+	}
+	return anyType
+}
+
+func (c *checker) identifier(ident *ast.Identifier, s *scope) Type {
+	if b, ok := s.lookup(ident.Value); ok {
+		return c.instantiate(b.scheme)
+	}
+	if builtin, ok := builtinTypes[ident.Value]; ok {
+		return builtin(c)
+	}
+	if evaluator.IsBuiltin(ident.Value) {
+		return anyType
+	}
+	c.report(ident.Token, "identifier not found: %s", ident.Value)
+	return anyType
+}
+
+func (c *checker) prefix(exp *ast.PrefixExpression, right Type) Type {
+	if exp.Operator == "!" {
+		return boolType
+	}
+
+	right = prune(right)
+	if right == anyType {
+		return anyType
+	}
+	c.kindError = anyKind
+	if !c.unify(right, c.fresh(numericKind)) {
+		c.report(exp.Token, "unknown operator: %s%s", exp.Operator, right)
+		return anyType
+	}
+	return right
+}
+
+// infix follows the evaluator: arithmetic takes numbers, + also takes two
+// strings, and comparisons for equality take anything
+func (c *checker) infix(exp *ast.InfixExpression, left, right Type) Type {
+	var result Type
+	switch exp.Operator {
+	case "==", "!=", "in":
+		return boolType
+	case "<", ">":
+		result = boolType
+	}
+
+	left, right = prune(left), prune(right)
+	if left == anyType || right == anyType {
+		if result == nil {
+			result = anyType
+		}
+		return result
+	}
+
+	operands := numericKind
+	if exp.Operator == "+" {
+		operands = addableKind
+	}
+	if isNumber(left) && isNumber(right) {
+		if result == nil && left == intType && right == intType {
+			result = intType
+		} else if result == nil {
+			result = floatType
+		}
+		return result
+	}
+
+	v := c.fresh(operands)
+	if !c.unify(left, v) || !c.unify(right, v) {
+		if left.String() == right.String() {
+			c.report(exp.Token, "unknown operator: %s %s %s", left, exp.Operator, right)
+		} else {
+			c.report(exp.Token, "type mismatch: %s %s %s", left, exp.Operator, right)
+		}
+		return anyType
+	}
+	if result == nil {
+		result = v
+	}
+	return result
+}
+
+func (c *checker) index(exp *ast.IndexExpression, left, index Type) Type {
+	switch left := prune(left).(type) {
+	case *arrayType:
+		c.expect(index, intType, ast.TokenOf(exp.Index), "array index")
+		return left.element
+	case *hashType:
+		c.expect(index, left.key, ast.TokenOf(exp.Index), "hash key")
+		return left.value
+	case *variable:
+		return anyType
+	case basic:
+		switch left {
+		case stringType:
+			c.expect(index, intType, ast.TokenOf(exp.Index), "string index")
+			return stringType
+		case anyType:
+			return anyType
+		}
+	}
+	c.report(exp.Token, "index operator not supported: %s", prune(left))
+	return anyType
+}
+
+func (c *checker) function(fn *ast.FunctionLiteral, outer *scope) Type {
+	s := newScope(outer)
+	fixed := len(fn.Parameters)
+	if fn.Variadic {
+		fixed--
+	}
+	t := &functionType{params: make([]Type, fixed), optional: fixed - fn.Required()}
+
+	for i, param := range fn.Parameters {
+		var pt Type
+		if annotation := fn.ParameterType(i); annotation != nil {
+			pt = c.annotation(annotation)
+		} else {
+			pt = c.fresh(anyKind)
+		}
+		if i == fixed {
+			rest := &arrayType{element: c.fresh(anyKind)}
+			c.expect(pt, rest, param.Token, "rest parameter "+param.Value)
+			pt, t.rest = rest, rest.element
+		} else {
+			t.params[i] = pt
+		}
+		if d := fn.ParameterDefault(i); d != nil {
+			c.expect(c.expression(d, s), pt, ast.TokenOf(d), "default of "+param.Value)
+		}
+
+		s.names[param.Value] = &binding{scheme: &scheme{t: pt}, defined: true}
+		if pattern := fn.ParameterPattern(i); pattern != nil {
+			c.pattern(pattern, pt, s)
+		}
+	}
+	switch {
+	case fn.ReturnType != nil:
+		t.result = c.annotation(fn.ReturnType)
+	case fn.Generator:
+		// calls return an iterator, which has no type of its own
+		t.result = anyType
+	default:
+		t.result = c.fresh(anyKind)
+	}
+	if fn.Body == nil {
+		return t
+	}
+
+	// what the body of a generator returns is dropped
+	result := t.result
+	if fn.Generator {
+		result = c.fresh(anyKind)
+	}
+	c.declare(fn.Body.Statements, s)
+	c.results = append(c.results, result)
+	value, falls := c.statements(fn.Body.Statements, s)
+	c.results = c.results[:len(c.results)-1]
+
+	if falls && !fn.Generator {
+		tok := fn.Body.Token
+		if n := len(fn.Body.Statements); n > 0 {
+			tok = ast.TokenOf(fn.Body.Statements[n-1])
+		}
+		c.expect(value, t.result, tok, "result")
+	}
+	return t
+}
+
+func (c *checker) call(exp *ast.CallExpression, s *scope) Type {
+	callee := c.expression(exp.Function, s)
+	args := make([]Type, len(exp.Arguments))
+	followed := true // whether each argument goes to the parameter at its index
+	for i, arg := range exp.Arguments {
+		args[i] = c.expression(arg, s)
+		switch arg.(type) {
+		case *ast.SpreadExpression, *ast.NamedArgument:
+			followed = false
+		}
+	}
+
+	switch fn := prune(callee).(type) {
+	case *functionType:
+		if !followed {
+			return fn.result
+		}
+		if c.functionFirst(exp, args, s) {
+			fn = &functionType{params: []Type{fn.params[1], fn.params[0]}, result: fn.result}
+		}
+		if len(args) < len(fn.params)-fn.optional || fn.rest == nil && len(args) > len(fn.params) {
+			c.report(exp.Token, "wrong number of arguments. got=%d, want=%s", len(args), arity(fn))
+			return fn.result
+		}
+		for i, arg := range args {
+			want := fn.rest
+			if i < len(fn.params) {
+				want = fn.params[i]
+			}
+			c.expect(arg, want, ast.TokenOf(exp.Arguments[i]), fmt.Sprintf("argument %d", i+1))
+		}
+		return fn.result
+	case *variable:
+		if !followed {
+			return anyType
+		}
+		result := c.fresh(anyKind)
+		c.expect(&functionType{params: args, result: result}, fn, exp.Token, "call")
+		return result
+	case basic:
+		if fn == anyType {
+			return anyType
+		}
+	}
+	c.report(exp.Token, "not a function: %s", prune(callee))
+	return anyType
+}
+
+// functionFirst reports whether exp calls map or filter with the function
+// before the sequence, which they accept as well as the other way round
+func (c *checker) functionFirst(exp *ast.CallExpression, args []Type, s *scope) bool {
+	ident, ok := exp.Function.(*ast.Identifier)
+	if !ok || !functionFirstBuiltins[ident.Value] || len(args) != 2 {
+		return false
+	}
+	if _, shadowed := s.lookup(ident.Value); shadowed {
+		return false
+	}
+	_, ok = prune(args[0]).(*functionType)
+	return ok
+}
+
+// arity describes the number of arguments functions of type fn take
+func arity(fn *functionType) string {
+	required := len(fn.params) - fn.optional
+	switch {
+	case fn.rest != nil:
+		return fmt.Sprintf("at least %d", required)
+	case fn.optional > 0:
+		return fmt.Sprintf("%d to %d", required, len(fn.params))
+	}
+	return fmt.Sprint(required)
+}
+
+// annotation returns the type an annotation names
+func (c *checker) annotation(t ast.TypeExpression) Type {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		switch basic(t.Name) {
+		case intType, floatType, stringType, boolType, nullType, anyType:
+			return basic(t.Name)
+		}
+		c.report(t.Token, "unknown type: %s", t.Name)
+		return anyType
+	case *ast.ArrayType:
+		return &arrayType{element: c.annotation(t.Element)}
+	case *ast.HashType:
+		return &hashType{key: c.annotation(t.Key), value: c.annotation(t.Value)}
+	case *ast.FunctionType:
+		params := make([]Type, len(t.Parameters))
+		for i, p := range t.Parameters {
+			params[i] = c.annotation(p)
+		}
+		return &functionType{params: params, result: c.annotation(t.Result)}
+	}
+	return anyType
+}
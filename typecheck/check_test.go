@@ -0,0 +1,132 @@
+package typecheck
+
+import (
+	"monkey_interpreter/evaluator"
+	"monkey_interpreter/lexer"
+	"monkey_interpreter/object"
+	"monkey_interpreter/parser"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x: int = 5; x + 1", nil},
+		{`let x: string = 5;`, []string{"1:17: let x: expected string, got int"}},
+		{"let x: float = 5; x * 1.5", nil},
+		{"1 + true", []string{"1:3: type mismatch: int + bool"}},
+		{"true + true", []string{"1:6: unknown operator: bool + bool"}},
+		{`"a" - "b"`, []string{"1:5: unknown operator: string - string"}},
+		{`-"a"; !"a"`, []string{"1:1: unknown operator: -string"}},
+		{"let add = fn(a: int, b: int) -> int { a + b }; add(1, \"2\")", []string{"1:55: argument 2: expected int, got string"}},
+		{"let add = fn(a, b) { a + b }; add(1, 2) + add(\"a\", \"b\")", []string{"1:41: type mismatch: int + string"}},
+		{"let add = fn(a, b) { a + b }; add(1, \"b\")", []string{"1:38: argument 2: expected int, got string"}},
+		{"let sub = fn(a, b) { a - b }; sub(\"a\", \"b\")", []string{"1:35: argument 1: expected a number, got string", "1:40: argument 2: expected a number, got string"}},
+		{"let id = fn(x) { x }; id(1) + 1; id(\"a\") + \"b\"", nil},
+		{"let f = fn(x) -> string { x }; f(1)", []string{"1:34: argument 1: expected string, got int"}},
+		{"let f = fn(x) -> bool { if (x) { return 1; }; true }", []string{"1:41: return: expected bool, got int"}},
+		{"let f = fn(a) { a }; f(1, 2)", []string{"1:23: wrong number of arguments. got=2, want=1"}},
+		{"let x = 1; x(2)", []string{"1:13: not a function: int"}},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(true)", []string{"1:70: argument 1: expected int, got bool"}},
+		{"let f = fn() { g(1) }; let g = fn(x: string) { x };", []string{"1:28: let g: expected fn(int) -> t3, got fn(string) -> string"}},
+		{"missing + 1", []string{"1:1: identifier not found: missing"}},
+		{"let x: integer = 1;", []string{"1:8: unknown type: integer"}},
+		{"[1, 2][0] + \"a\"; \"abc\"[\"b\"]", []string{"1:11: type mismatch: int + string", "1:24: string index: expected int, got string"}},
+		{"{\"a\": 1}[2]; 5[0]; 5.x; 5[1:]", []string{"1:10: hash key: expected string, got int", "1:15: index operator not supported: int", "1:21: member access not supported: int", "1:26: slice operator not supported: int"}},
+		{"map([1, 2], fn(x) { x * 2 })[0] + \"a\"", []string{"1:33: type mismatch: int + string"}},
+		{"reduce([1, 2], \"\", fn(acc, x) { acc + x })", []string{"1:20: argument 3: expected fn(string, int) -> string, got fn(t5, t5) -> t5"}},
+		{"match ([1, 2]) { [a, ...rest] => a + rest[0], _ => 0 } + \"a\"", []string{"1:56: type mismatch: int + string"}},
+		{"match ({\"a\": \"b\"}) { {\"a\": a} if a - 1 => a }", []string{"1:36: type mismatch: string - int"}},
+		{"let [a, b] = [1, 2]; a + \"x\"", []string{"1:24: type mismatch: int + string"}},
+		{"let {name, age = 0} = {\"name\": \"x\"}; name - 1; age", []string{"1:43: type mismatch: string - int"}},
+		{"let f = fn([a, b]: [string]) { a - b }; f([\"x\"])", []string{"1:34: unknown operator: string - string"}},
+		{"let [a]: [int] = [\"x\"];", []string{"1:18: let [a]: expected [int], got [string]"}},
+		{"let f = fn() { later + 1 }; let [later] = [\"a\"];", []string{"1:34: later: expected int, got string"}},
+		{"let f = fn(x, y = 1) { x + y }; f(1) + f(1, 2); f(\"a\"); f()", []string{"1:51: argument 1: expected int, got string", "1:58: wrong number of arguments. got=0, want=1 to 2"}},
+		{"let f = fn(x: int = \"a\") { x }", []string{"1:21: default of x: expected int, got string"}},
+		{"let f = fn(a, ...xs) { xs[0] + a }; f(1, 2, 3); f(1, \"b\"); f()", []string{"1:54: argument 2: expected int, got string", "1:61: wrong number of arguments. got=0, want=at least 1"}},
+		{"let f = fn(...xs: int) { xs }", []string{"1:15: rest parameter xs: expected [t2], got int"}},
+		{"let f = fn(x) { x }; f(...5)", []string{"1:27: spread: expected [t5], got int"}},
+		{"map([1], fn(x, y = 2) { x * y })[0] + \"a\"", []string{"1:37: type mismatch: int + string"}},
+		{"map(fn(x) { x * 2 }, [1, 2])[0] + \"a\"", []string{"1:33: type mismatch: int + string"}},
+		{"filter(fn(x) { x - 1 }, [\"a\"])", []string{"1:25: argument 2: expected [int], got [string]"}},
+		{`"n = ${1 + true}" + 1`, []string{"1:10: type mismatch: int + bool", "1:19: type mismatch: string + int"}},
+		{"for ([a, b] in [[1, 2]]) { a + \"x\" }; for (c in \"abc\") { c - 1 }", []string{"1:30: type mismatch: int + string", "1:60: type mismatch: string - int"}},
+		{"let g = fn(x) -> int { yield x + 1; return \"done\" }; g(\"a\")", []string{"1:56: argument 1: expected int, got string"}},
+		{"let f = fn(g: fn(int) -> int) { g(1) }; f(fn(x) { x + 1 }); f(upper)", []string{"1:63: argument 1: expected fn(int) -> int, got fn(string) -> string"}},
+
+		// code the checker cannot follow is accepted
+		{`let h = {"name": "bob", "age": 3}; h.name + 1; h["age"] * 2`, nil},
+		{`let mixed = [1, "a"]; mixed[0] + 1; mixed[1] + "b"`, nil},
+		{`let x = if (true) { 1 }; x + "a"`, nil},
+		{`let x = if (true) { 1 } else { "a" }; x + 1`, nil},
+		{`let x = if (true) { 1 } else { 2.5 }; x * 2`, nil},
+		{`let json = import("json"); json.parse("1") + 1; puts(1, "a")`, nil},
+		{`let f = fn(x) { x[0] }; f([1]) + f("a")`, nil},
+		{"let f = fn() { later + 1 }; let later = 2; f()", nil},
+		{`match (1) { 1 => "one", _ => 2 } + 1`, nil},
+		{`let f = fn(x, y) { x + y }; f(y: 1, x: 2) + f(...[1, 2])`, nil},
+		{`let isOdd = fn(x) { x / 2 * 2 != x }; take(10, filter(isOdd, range(1000)))`, nil},
+		{`let f = fn([a, b]) { a + b }; f([1, 2]) + f(["a", "b"])`, nil},
+		{`let g = fn() { yield 1; yield "a" }; for (x in g()) { x + 1 }; collect(g())[0] + 1`, nil},
+	}
+
+	for _, tt := range tests {
+		errors, parseErrors := Source(tt.input)
+		if len(parseErrors) != 0 {
+			t.Errorf("parser errors for %q: %v", tt.input, parseErrors)
+			continue
+		}
+
+		got := make([]string, len(errors))
+		for i, e := range errors {
+			got[i] = e.String()
+		}
+		if len(got) != len(tt.expected) {
+			t.Errorf("%q: expected errors %q. got=%q", tt.input, tt.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("%q: expected errors %q. got=%q", tt.input, tt.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestAnnotationsDoNotChangeResults(t *testing.T) {
+	tests := []struct {
+		annotated string
+		plain     string
+	}{
+		{
+			"let add = fn(a: int, b: int) -> int { a + b }; let x: int = add(1, 2); x",
+			"let add = fn(a, b) { a + b }; let x = add(1, 2); x",
+		},
+		{
+			`let greet = fn(names: [string], sep: string) -> string { join(names, sep) }; greet(["a", "b"], "-")`,
+			`let greet = fn(names, sep) { join(names, sep) }; greet(["a", "b"], "-")`,
+		},
+	}
+
+	for _, tt := range tests {
+		results := []string{}
+		for _, input := range []string{tt.annotated, tt.plain} {
+			p := parser.New(lexer.New(input))
+			program := p.ParseProgram()
+			if len(p.Errors()) != 0 {
+				t.Fatalf("parser errors for %q: %v", input, p.Errors())
+			}
+			if errors := Check(program); len(errors) != 0 {
+				t.Errorf("unexpected type errors for %q: %v", input, errors)
+			}
+			results = append(results, evaluator.Eval(program, object.NewEnvironment()).Inspect())
+		}
+		if results[0] != results[1] {
+			t.Errorf("annotations changed the result: %s != %s", results[0], results[1])
+		}
+	}
+}
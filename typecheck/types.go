@@ -0,0 +1,360 @@
+package typecheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type is a type the checker has inferred or read from an annotation
+type Type interface {
+	String() string
+}
+
+// basic is a type with no parts
+type basic string
+
+const (
+	intType    basic = "int"
+	floatType  basic = "float"
+	stringType basic = "string"
+	boolType   basic = "bool"
+	nullType   basic = "null"
+	// anyType is the type of values the checker cannot follow. It matches
+	// every other type, so code using them is never rejected.
+	anyType basic = "any"
+)
+
+func (b basic) String() string { return string(b) }
+
+type arrayType struct {
+	element Type
+}
+
+func (a *arrayType) String() string { return "[" + a.element.String() + "]" }
+
+type hashType struct {
+	key, value Type
+}
+
+func (h *hashType) String() string { return "{" + h.key.String() + ": " + h.value.String() + "}" }
+
+// functionType is the type of functions taking params, the last optional of
+// which may be left out, and any number of arguments of type rest after them
+// unless rest is nil
+type functionType struct {
+	params   []Type
+	optional int
+	rest     Type
+	result   Type
+}
+
+func (f *functionType) String() string {
+	params := make([]string, len(f.params))
+	for i, p := range f.params {
+		params[i] = p.String()
+		if i >= len(f.params)-f.optional {
+			params[i] += "?"
+		}
+	}
+	if f.rest != nil {
+		params = append(params, "..."+f.rest.String())
+	}
+	return "fn(" + strings.Join(params, ", ") + ") -> " + f.result.String()
+}
+
+// param returns the type of argument i of calls of f, or nil if it takes fewer
+func (f *functionType) param(i int) Type {
+	if i < len(f.params) {
+		return f.params[i]
+	}
+	return f.rest
+}
+
+// overlaps reports whether some number of arguments suits both f and g, which
+// is all that is asked of functions with defaults and rest parameters, so one
+// may be passed where a function taking fewer or more arguments is expected
+func (f *functionType) overlaps(g *functionType) bool {
+	fits := func(n int, h *functionType) bool {
+		return n >= len(h.params)-h.optional && (h.rest != nil || n <= len(h.params))
+	}
+	for n := 0; n <= len(f.params) || n <= len(g.params); n++ {
+		if fits(n, f) && fits(n, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// kind limits the types a variable may stand for, which is how operators
+// accept more than one type without overloading
+type kind int
+
+const (
+	anyKind     kind = iota
+	addableKind      // int, float or string, the operands of +
+	numericKind      // int or float
+)
+
+func (k kind) allows(t basic) bool {
+	switch k {
+	case numericKind:
+		return t == intType || t == floatType
+	case addableKind:
+		return t == intType || t == floatType || t == stringType
+	}
+	return true
+}
+
+func (k kind) String() string {
+	if k == numericKind {
+		return "a number"
+	}
+	return "a number or string"
+}
+
+// variable is a type not yet known. Once unification finds what it stands for
+// it becomes an alias of instance. level is the number of lets whose value is
+// being checked when it was made, and only variables deeper than the let
+// being generalized are made generic.
+type variable struct {
+	id       int
+	level    int
+	kind     kind
+	instance Type
+}
+
+func (v *variable) String() string {
+	if v.instance != nil {
+		return v.instance.String()
+	}
+	return fmt.Sprintf("t%d", v.id)
+}
+
+// prune follows the instances of variables to the type they stand for
+func prune(t Type) Type {
+	for {
+		v, ok := t.(*variable)
+		if !ok || v.instance == nil {
+			return t
+		}
+		t = v.instance
+	}
+}
+
+// isNumber reports whether t is known to be int or float
+func isNumber(t Type) bool {
+	return t == intType || t == floatType
+}
+
+// scheme is the type of a let bound function, generic over vars, which are
+// replaced by new variables at each use
+type scheme struct {
+	vars []*variable
+	t    Type
+}
+
+// trailEntry records a variable as it was before unification changed it
+type trailEntry struct {
+	v        *variable
+	level    int
+	kind     kind
+	instance Type
+}
+
+// save records v so a failed unification can restore it
+func (c *checker) save(v *variable) {
+	c.trail = append(c.trail, trailEntry{v: v, level: v.level, kind: v.kind, instance: v.instance})
+}
+
+// unify makes got and want the same type, binding variables as needed, and
+// reports whether it could. A failed unification changes nothing.
+func (c *checker) unify(got, want Type) bool {
+	mark := len(c.trail)
+	ok := c.unifies(got, want)
+	if !ok {
+		for i := len(c.trail) - 1; i >= mark; i-- {
+			e := c.trail[i]
+			e.v.level, e.v.kind, e.v.instance = e.level, e.kind, e.instance
+		}
+	}
+	c.trail = c.trail[:mark]
+	return ok
+}
+
+func (c *checker) unifies(a, b Type) bool {
+	a, b = prune(a), prune(b)
+	if v, ok := a.(*variable); ok {
+		return c.bind(v, b)
+	}
+	if v, ok := b.(*variable); ok {
+		return c.bind(v, a)
+	}
+	if a == anyType || b == anyType {
+		return true
+	}
+
+	switch a := a.(type) {
+	case basic:
+		return a == b
+	case *arrayType:
+		b, ok := b.(*arrayType)
+		return ok && c.unifies(a.element, b.element)
+	case *hashType:
+		b, ok := b.(*hashType)
+		return ok && c.unifies(a.key, b.key) && c.unifies(a.value, b.value)
+	case *functionType:
+		b, ok := b.(*functionType)
+		if !ok || !a.overlaps(b) {
+			return false
+		}
+		for i := 0; i < len(a.params) || i < len(b.params); i++ {
+			pa, pb := a.param(i), b.param(i)
+			if pa != nil && pb != nil && !c.unifies(pa, pb) {
+				return false
+			}
+		}
+		if a.rest != nil && b.rest != nil && !c.unifies(a.rest, b.rest) {
+			return false
+		}
+		return c.unifies(a.result, b.result)
+	}
+	return false
+}
+
+// bind makes the unbound variable v stand for t
+func (c *checker) bind(v *variable, t Type) bool {
+	if t == v || t == anyType {
+		return true
+	}
+
+	if other, ok := t.(*variable); ok {
+		c.save(other)
+		if v.kind > other.kind {
+			other.kind = v.kind
+		}
+		if v.level < other.level {
+			other.level = v.level
+		}
+	} else {
+		if b, ok := t.(basic); v.kind != anyKind && (!ok || !v.kind.allows(b)) {
+			c.kindError = v.kind
+			return false
+		}
+		if !c.adjust(t, v) {
+			return false
+		}
+	}
+
+	c.save(v)
+	v.instance = t
+	return true
+}
+
+// adjust lowers the level of the variables in t to that of v, so they are
+// generalized no sooner than v, and reports whether t is free of v
+func (c *checker) adjust(t Type, v *variable) bool {
+	switch t := prune(t).(type) {
+	case *variable:
+		if t == v {
+			return false
+		}
+		if t.level > v.level {
+			c.save(t)
+			t.level = v.level
+		}
+	case *arrayType:
+		return c.adjust(t.element, v)
+	case *hashType:
+		return c.adjust(t.key, v) && c.adjust(t.value, v)
+	case *functionType:
+		for _, p := range t.params {
+			if !c.adjust(p, v) {
+				return false
+			}
+		}
+		if t.rest != nil && !c.adjust(t.rest, v) {
+			return false
+		}
+		return c.adjust(t.result, v)
+	}
+	return true
+}
+
+// fresh returns a new variable at the current level
+func (c *checker) fresh(k kind) *variable {
+	c.vars++
+	return &variable{id: c.vars, level: c.level, kind: k}
+}
+
+// generalize returns a scheme for t generic over the variables made while
+// checking the value of the current let
+func (c *checker) generalize(t Type) *scheme {
+	s := &scheme{t: t}
+	seen := make(map[*variable]bool)
+
+	var walk func(t Type)
+	walk = func(t Type) {
+		switch t := prune(t).(type) {
+		case *variable:
+			if t.level > c.level && !seen[t] {
+				seen[t] = true
+				s.vars = append(s.vars, t)
+			}
+		case *arrayType:
+			walk(t.element)
+		case *hashType:
+			walk(t.key)
+			walk(t.value)
+		case *functionType:
+			for _, p := range t.params {
+				walk(p)
+			}
+			if t.rest != nil {
+				walk(t.rest)
+			}
+			walk(t.result)
+		}
+	}
+	walk(t)
+
+	return s
+}
+
+// instantiate returns the type of s with new variables for the generic ones
+func (c *checker) instantiate(s *scheme) Type {
+	if len(s.vars) == 0 {
+		return s.t
+	}
+	fresh := make(map[*variable]Type, len(s.vars))
+	for _, v := range s.vars {
+		fresh[v] = c.fresh(v.kind)
+	}
+
+	var copy func(t Type) Type
+	copy = func(t Type) Type {
+		switch t := prune(t).(type) {
+		case *variable:
+			if f, ok := fresh[t]; ok {
+				return f
+			}
+			return t
+		case *arrayType:
+			return &arrayType{element: copy(t.element)}
+		case *hashType:
+			return &hashType{key: copy(t.key), value: copy(t.value)}
+		case *functionType:
+			params := make([]Type, len(t.params))
+			for i, p := range t.params {
+				params[i] = copy(p)
+			}
+			f := &functionType{params: params, optional: t.optional, result: copy(t.result)}
+			if t.rest != nil {
+				f.rest = copy(t.rest)
+			}
+			return f
+		default:
+			return t
+		}
+	}
+	return copy(s.t)
+}
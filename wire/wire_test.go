@@ -0,0 +1,43 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, map[string]int{"seq": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&buf, []string{"héllo"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String()[:20] != "Content-Length: 9\r\n\r" {
+		t.Errorf("wrong framing. got=%q", buf.String())
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, expected := range []string{`{"seq":1}`, `["héllo"]`} {
+		body, err := Read(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != expected {
+			t.Errorf("wrong body. want=%q, got=%q", expected, body)
+		}
+	}
+
+	if _, err := Read(r); err == nil {
+		t.Errorf("expected an error at end of input")
+	}
+}
+
+func TestInvalidHeader(t *testing.T) {
+	r := bufio.NewReader(bytes.NewBufferString("Content-Length: abc\r\n\r\n{}"))
+	if _, err := Read(r); err == nil {
+		t.Errorf("expected an error for a bad Content-Length")
+	}
+}